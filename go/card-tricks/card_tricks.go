@@ -8,31 +8,30 @@ func FavoriteCards() []int {
 // GetItem retrieves an item from a slice at given position.
 // If the index is out of range, we want it to return -1.
 func GetItem(slice []int, index int) int {
-	if index < 0 || index >= len(slice) {
+	value, err := Get(slice, index)
+	if err != nil {
 		return -1
 	}
-	return slice[index]
+	return value
 }
 
 // SetItem writes an item to a slice at given position overwriting an existing value.
 // If the index is out of range the value needs to be appended.
 func SetItem(slice []int, index, value int) []int {
-	if index < 0 || index >= len(slice) {
+	if err := Set(slice, index, value); err != nil {
 		return append(slice, value)
 	}
-	slice[index] = value
 	return slice
 }
 
 // PrependItems adds an arbitrary number of values at the front of a slice.
 func PrependItems(slice []int, values ...int) []int {
-	return append(values, slice...)
+	return Prepend(slice, values...)
 }
 
 // RemoveItem removes an item from a slice by modifying the existing slice.
+// If the index is out of range the slice is returned unchanged.
 func RemoveItem(slice []int, index int) []int {
-	if index < 0 || index >= len(slice) {
-		return slice
-	}
-	return append(slice[:index], slice[index+1:]...)
+	result, _ := Remove(slice, index)
+	return result
 }
@@ -0,0 +1,88 @@
+package cards
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrIndexOutOfRange is returned when an index does not address an element of a slice.
+var ErrIndexOutOfRange = errors.New("index out of range")
+
+// outOfRange wraps ErrIndexOutOfRange with the offending index and the slice length.
+func outOfRange(index, length int) error {
+	return fmt.Errorf("%w: index %d, length %d", ErrIndexOutOfRange, index, length)
+}
+
+// Get retrieves the item at the given position.
+func Get[T any](slice []T, index int) (T, error) {
+	if index < 0 || index >= len(slice) {
+		var zero T
+		return zero, outOfRange(index, len(slice))
+	}
+	return slice[index], nil
+}
+
+// Set overwrites the item at the given position in place.
+func Set[T any](slice []T, index int, value T) error {
+	if index < 0 || index >= len(slice) {
+		return outOfRange(index, len(slice))
+	}
+	slice[index] = value
+	return nil
+}
+
+// Insert adds values before the given position. An index equal to the
+// length of the slice appends the values at the end.
+func Insert[T any](slice []T, index int, values ...T) ([]T, error) {
+	if index < 0 || index > len(slice) {
+		return slice, outOfRange(index, len(slice))
+	}
+	result := make([]T, 0, len(slice)+len(values))
+	result = append(result, slice[:index]...)
+	result = append(result, values...)
+	return append(result, slice[index:]...), nil
+}
+
+// Prepend adds an arbitrary number of values at the front of a slice.
+func Prepend[T any](slice []T, values ...T) []T {
+	return append(values, slice...)
+}
+
+// Remove removes the item at the given position by modifying the existing slice.
+func Remove[T any](slice []T, index int) ([]T, error) {
+	if index < 0 || index >= len(slice) {
+		return slice, outOfRange(index, len(slice))
+	}
+	return append(slice[:index], slice[index+1:]...), nil
+}
+
+// Move takes the item at position from and places it at position to,
+// shifting the items in between by one.
+func Move[T any](slice []T, from, to int) error {
+	if from < 0 || from >= len(slice) {
+		return outOfRange(from, len(slice))
+	}
+	if to < 0 || to >= len(slice) {
+		return outOfRange(to, len(slice))
+	}
+	item := slice[from]
+	if from < to {
+		copy(slice[from:to], slice[from+1:to+1])
+	} else {
+		copy(slice[to+1:from+1], slice[to:from])
+	}
+	slice[to] = item
+	return nil
+}
+
+// Swap exchanges the items at positions i and j.
+func Swap[T any](slice []T, i, j int) error {
+	if i < 0 || i >= len(slice) {
+		return outOfRange(i, len(slice))
+	}
+	if j < 0 || j >= len(slice) {
+		return outOfRange(j, len(slice))
+	}
+	slice[i], slice[j] = slice[j], slice[i]
+	return nil
+}
@@ -0,0 +1,166 @@
+package cards
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestGet(t *testing.T) {
+	tests := []struct {
+		name    string
+		slice   []int
+		index   int
+		want    int
+		wantErr bool
+	}{
+		{
+			name:  "Get a card with value -1",
+			slice: []int{5, -1, 10},
+			index: 1,
+			want:  -1,
+		},
+		{
+			name:    "Index out of bounds",
+			slice:   []int{5, -1, 10},
+			index:   3,
+			wantErr: true,
+		},
+		{
+			name:    "Negative index",
+			slice:   []int{5, -1, 10},
+			index:   -1,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Get(tt.slice, tt.index)
+			if tt.wantErr {
+				if !errors.Is(err, ErrIndexOutOfRange) {
+					t.Fatalf("Get(slice:%v, index:%v) error = %v, want %v", tt.slice, tt.index, err, ErrIndexOutOfRange)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("Get(slice:%v, index:%v) = %v, %v, want %v, nil", tt.slice, tt.index, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSet(t *testing.T) {
+	slice := []string{"ace", "king", "queen"}
+	if err := Set(slice, 1, "jack"); err != nil {
+		t.Fatalf("Set(slice, 1, jack) error = %v", err)
+	}
+	if slice[1] != "jack" {
+		t.Errorf("Set(slice, 1, jack) left %v", slice)
+	}
+	if err := Set(slice, 3, "ten"); !errors.Is(err, ErrIndexOutOfRange) {
+		t.Errorf("Set(slice, 3, ten) error = %v, want %v", err, ErrIndexOutOfRange)
+	}
+	if len(slice) != 3 {
+		t.Errorf("Set(slice, 3, ten) changed the length to %d", len(slice))
+	}
+}
+
+func TestInsert(t *testing.T) {
+	tests := []struct {
+		name    string
+		slice   []int
+		index   int
+		values  []int
+		want    []int
+		wantErr bool
+	}{
+		{
+			name:   "Insert in the middle",
+			slice:  []int{1, 2, 3},
+			index:  1,
+			values: []int{7, 8},
+			want:   []int{1, 7, 8, 2, 3},
+		},
+		{
+			name:   "Insert at the front",
+			slice:  []int{1, 2, 3},
+			index:  0,
+			values: []int{7},
+			want:   []int{7, 1, 2, 3},
+		},
+		{
+			name:   "Insert at the end",
+			slice:  []int{1, 2, 3},
+			index:  3,
+			values: []int{7},
+			want:   []int{1, 2, 3, 7},
+		},
+		{
+			name:    "Index out of bounds",
+			slice:   []int{1, 2, 3},
+			index:   4,
+			values:  []int{7},
+			want:    []int{1, 2, 3},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Insert(tt.slice, tt.index, tt.values...)
+			if tt.wantErr != errors.Is(err, ErrIndexOutOfRange) {
+				t.Fatalf("Insert(slice:%v, index:%v) error = %v", tt.slice, tt.index, err)
+			}
+			if !slicesEqual(got, tt.want) {
+				t.Errorf("Insert(slice:%v, index:%v, values:%v) = %v, want %v", tt.slice, tt.index, tt.values, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRemove(t *testing.T) {
+	got, err := Remove([]int{3, 4, 5}, 1)
+	if err != nil || !slicesEqual(got, []int{3, 5}) {
+		t.Errorf("Remove([3 4 5], 1) = %v, %v, want [3 5], nil", got, err)
+	}
+	got, err = Remove([]int{3, 4, 5}, 3)
+	if !errors.Is(err, ErrIndexOutOfRange) || !slicesEqual(got, []int{3, 4, 5}) {
+		t.Errorf("Remove([3 4 5], 3) = %v, %v, want [3 4 5], %v", got, err, ErrIndexOutOfRange)
+	}
+}
+
+func TestMove(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    int
+		to      int
+		want    []int
+		wantErr bool
+	}{
+		{name: "Move forwards", from: 0, to: 3, want: []int{2, 3, 4, 1, 5}},
+		{name: "Move backwards", from: 4, to: 1, want: []int{1, 5, 2, 3, 4}},
+		{name: "Move in place", from: 2, to: 2, want: []int{1, 2, 3, 4, 5}},
+		{name: "From out of bounds", from: 5, to: 0, want: []int{1, 2, 3, 4, 5}, wantErr: true},
+		{name: "To out of bounds", from: 0, to: -1, want: []int{1, 2, 3, 4, 5}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			slice := []int{1, 2, 3, 4, 5}
+			err := Move(slice, tt.from, tt.to)
+			if tt.wantErr != errors.Is(err, ErrIndexOutOfRange) {
+				t.Fatalf("Move(from:%v, to:%v) error = %v", tt.from, tt.to, err)
+			}
+			if !slicesEqual(slice, tt.want) {
+				t.Errorf("Move(from:%v, to:%v) = %v, want %v", tt.from, tt.to, slice, tt.want)
+			}
+		})
+	}
+}
+
+func TestSwap(t *testing.T) {
+	slice := []int{1, 2, 3}
+	if err := Swap(slice, 0, 2); err != nil || !slicesEqual(slice, []int{3, 2, 1}) {
+		t.Errorf("Swap([1 2 3], 0, 2) = %v, %v, want [3 2 1], nil", slice, err)
+	}
+	if err := Swap(slice, 0, 3); !errors.Is(err, ErrIndexOutOfRange) {
+		t.Errorf("Swap(slice, 0, 3) error = %v, want %v", err, ErrIndexOutOfRange)
+	}
+}
@@ -0,0 +1,187 @@
+package cards
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// ErrUnknownOp is returned when a command has an operation this package does not know.
+var ErrUnknownOp = errors.New("unknown operation")
+
+// ErrNotApplied is returned when undoing a command that carries no record of being applied.
+var ErrNotApplied = errors.New("command has not been applied")
+
+// ErrNothingToUndo is returned by Log.Undo when no applied command is left.
+var ErrNothingToUndo = errors.New("nothing to undo")
+
+// ErrNothingToRedo is returned by Log.Redo when no undone command is left.
+var ErrNothingToRedo = errors.New("nothing to redo")
+
+// Op names a mutation of a deck.
+type Op string
+
+const (
+	// OpSet performs SetItem(deck, Index, Value).
+	OpSet Op = "set"
+	// OpPrepend performs PrependItems(deck, Values...).
+	OpPrepend Op = "prepend"
+	// OpRemove performs RemoveItem(deck, Index).
+	OpRemove Op = "remove"
+)
+
+// Command is a recordable call to SetItem, PrependItems or RemoveItem.
+// Apply fills in Applied and Previous, which is what Undo needs to
+// reverse the call.
+type Command struct {
+	Op     Op    `json:"op"`
+	Index  int   `json:"index,omitempty"`
+	Value  int   `json:"value,omitempty"`
+	Values []int `json:"values,omitempty"`
+
+	// Applied is set once the command has been performed on a deck.
+	Applied bool `json:"applied,omitempty"`
+	// Previous holds the card overwritten by a set or taken out by a
+	// remove. It is nil when a set appended or a remove was out of range.
+	Previous *int `json:"previous,omitempty"`
+}
+
+// SetCommand returns a command that calls SetItem.
+func SetCommand(index, value int) Command {
+	return Command{Op: OpSet, Index: index, Value: value}
+}
+
+// PrependCommand returns a command that calls PrependItems.
+func PrependCommand(values ...int) Command {
+	return Command{Op: OpPrepend, Values: slices.Clone(values)}
+}
+
+// RemoveCommand returns a command that calls RemoveItem.
+func RemoveCommand(index int) Command {
+	return Command{Op: OpRemove, Index: index}
+}
+
+// Apply performs the command on deck. It returns the resulting deck and
+// a copy of the command that records what is needed to undo it.
+func (c Command) Apply(deck []int) ([]int, Command, error) {
+	done := c
+	done.Applied = true
+	done.Previous = nil
+	switch c.Op {
+	case OpSet:
+		if previous, err := Get(deck, c.Index); err == nil {
+			done.Previous = &previous
+		}
+		return SetItem(deck, c.Index, c.Value), done, nil
+	case OpPrepend:
+		// Prepend builds on its values, so hand it a copy the result may own.
+		return PrependItems(deck, slices.Clone(c.Values)...), done, nil
+	case OpRemove:
+		if previous, err := Get(deck, c.Index); err == nil {
+			done.Previous = &previous
+		}
+		return RemoveItem(deck, c.Index), done, nil
+	default:
+		return deck, c, fmt.Errorf("%w: %q", ErrUnknownOp, c.Op)
+	}
+}
+
+// Undo reverses an applied command on the deck it returned.
+func (c Command) Undo(deck []int) ([]int, error) {
+	if !c.Applied {
+		return deck, ErrNotApplied
+	}
+	switch c.Op {
+	case OpSet:
+		if c.Previous == nil {
+			// The value was appended, so it is the last card.
+			if len(deck) == 0 {
+				return deck, outOfRange(0, 0)
+			}
+			return deck[:len(deck)-1], nil
+		}
+		if err := Set(deck, c.Index, *c.Previous); err != nil {
+			return deck, err
+		}
+		return deck, nil
+	case OpPrepend:
+		if len(c.Values) > len(deck) {
+			return deck, outOfRange(len(c.Values), len(deck))
+		}
+		return deck[len(c.Values):], nil
+	case OpRemove:
+		if c.Previous == nil {
+			return deck, nil
+		}
+		return Insert(deck, c.Index, *c.Previous)
+	default:
+		return deck, fmt.Errorf("%w: %q", ErrUnknownOp, c.Op)
+	}
+}
+
+// Replay applies commands in order to deck, ignoring anything they recorded
+// on another deck, and returns the result with freshly recorded commands.
+func Replay(deck []int, commands ...Command) ([]int, []Command, error) {
+	done := make([]Command, 0, len(commands))
+	for i, c := range commands {
+		var err error
+		deck, c, err = c.Apply(deck)
+		if err != nil {
+			return deck, done, fmt.Errorf("command %d: %w", i, err)
+		}
+		done = append(done, c)
+	}
+	return deck, done, nil
+}
+
+// Log is an undoable history of commands. The first Position commands
+// have been applied; the rest were undone and can be redone until a new
+// command is recorded. A Log serializes to JSON as is.
+type Log struct {
+	Commands []Command `json:"commands"`
+	Position int       `json:"position"`
+}
+
+// Do applies the command to deck and records it, discarding any commands
+// that were undone.
+func (l *Log) Do(deck []int, c Command) ([]int, error) {
+	deck, done, err := c.Apply(deck)
+	if err != nil {
+		return deck, err
+	}
+	l.Commands = append(l.Commands[:l.Position], done)
+	l.Position++
+	return deck, nil
+}
+
+// Undo reverses the most recently applied command.
+func (l *Log) Undo(deck []int) ([]int, error) {
+	if l.Position == 0 {
+		return deck, ErrNothingToUndo
+	}
+	deck, err := l.Commands[l.Position-1].Undo(deck)
+	if err != nil {
+		return deck, err
+	}
+	l.Position--
+	return deck, nil
+}
+
+// Redo applies the most recently undone command again.
+func (l *Log) Redo(deck []int) ([]int, error) {
+	if l.Position == len(l.Commands) {
+		return deck, ErrNothingToRedo
+	}
+	deck, done, err := l.Commands[l.Position].Apply(deck)
+	if err != nil {
+		return deck, err
+	}
+	l.Commands[l.Position] = done
+	l.Position++
+	return deck, nil
+}
+
+// Applied returns the commands that are currently in effect.
+func (l *Log) Applied() []Command {
+	return l.Commands[:l.Position]
+}
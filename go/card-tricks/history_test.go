@@ -0,0 +1,129 @@
+package cards
+
+import (
+	"encoding/json"
+	"errors"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func randomCommand(rng *rand.Rand, size int) Command {
+	// Indexes reach one past either end so that out-of-range calls are covered too.
+	index := rng.IntN(size+3) - 1
+	switch rng.IntN(3) {
+	case 0:
+		return SetCommand(index, rng.IntN(20)-1)
+	case 1:
+		values := make([]int, rng.IntN(3))
+		for i := range values {
+			values[i] = rng.IntN(20) - 1
+		}
+		return PrependCommand(values...)
+	default:
+		return RemoveCommand(index)
+	}
+}
+
+func TestCommandRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewPCG(3, 4))
+	for i := 0; i < 1000; i++ {
+		original := make([]int, rng.IntN(6))
+		for j := range original {
+			original[j] = rng.IntN(20) - 1
+		}
+		c := randomCommand(rng, len(original))
+
+		changed, done, err := c.Apply(copySlice(original))
+		if err != nil {
+			t.Fatalf("%+v.Apply(%v) error = %v", c, original, err)
+		}
+		restored, err := done.Undo(changed)
+		if err != nil {
+			t.Fatalf("%+v.Undo(%v) error = %v", done, changed, err)
+		}
+		if !slicesEqual(restored, original) {
+			t.Fatalf("%+v applied to %v and undone = %v", c, original, restored)
+		}
+	}
+}
+
+func TestLogUndoRedo(t *testing.T) {
+	var log Log
+	deck := FavoriteCards()
+	var err error
+	for _, c := range []Command{SetCommand(1, -1), PrependCommand(4, 5), RemoveCommand(4), SetCommand(9, 7)} {
+		if deck, err = log.Do(deck, c); err != nil {
+			t.Fatalf("Do(%+v) error = %v", c, err)
+		}
+	}
+	if want := []int{4, 5, 2, -1, 7}; !slicesEqual(deck, want) {
+		t.Fatalf("after Do deck = %v, want %v", deck, want)
+	}
+
+	states := [][]int{{4, 5, 2, -1}, {4, 5, 2, -1, 9}, {2, -1, 9}, {2, 6, 9}}
+	for _, want := range states {
+		if deck, err = log.Undo(deck); err != nil || !slicesEqual(deck, want) {
+			t.Fatalf("Undo() = %v, %v, want %v, nil", deck, err, want)
+		}
+	}
+	if _, err := log.Undo(deck); !errors.Is(err, ErrNothingToUndo) {
+		t.Errorf("Undo() on empty history error = %v, want %v", err, ErrNothingToUndo)
+	}
+
+	if deck, err = log.Redo(deck); err != nil || !slicesEqual(deck, []int{2, -1, 9}) {
+		t.Fatalf("Redo() = %v, %v, want [2 -1 9], nil", deck, err)
+	}
+	if deck, err = log.Do(deck, RemoveCommand(0)); err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if len(log.Commands) != 2 || log.Position != 2 {
+		t.Errorf("Do() after Undo kept %d commands at position %d, want 2 at 2", len(log.Commands), log.Position)
+	}
+	if _, err := log.Redo(deck); !errors.Is(err, ErrNothingToRedo) {
+		t.Errorf("Redo() after Do error = %v, want %v", err, ErrNothingToRedo)
+	}
+}
+
+func TestLogJSON(t *testing.T) {
+	var log Log
+	deck, _ := log.Do(FavoriteCards(), SetCommand(0, 3))
+	deck, _ = log.Do(deck, RemoveCommand(1))
+
+	data, err := json.Marshal(&log)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	var decoded Log
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("json.Unmarshal(%s) error = %v", data, err)
+	}
+	deck, _ = decoded.Undo(deck)
+	deck, _ = decoded.Undo(deck)
+	if !slicesEqual(deck, FavoriteCards()) {
+		t.Errorf("undoing a decoded log = %v, want %v", deck, FavoriteCards())
+	}
+}
+
+func TestReplay(t *testing.T) {
+	commands := []Command{PrependCommand(1), SetCommand(1, 8), RemoveCommand(3)}
+	got, done, err := Replay([]int{10, 20, 30}, commands...)
+	if err != nil || !slicesEqual(got, []int{1, 8, 20}) {
+		t.Fatalf("Replay() = %v, %v, want [1 8 20], nil", got, err)
+	}
+	for i := len(done) - 1; i >= 0; i-- {
+		got, _ = done[i].Undo(got)
+	}
+	if !slicesEqual(got, []int{10, 20, 30}) {
+		t.Errorf("undoing a replay = %v, want [10 20 30]", got)
+	}
+	if slices.ContainsFunc(commands, func(c Command) bool { return c.Applied }) {
+		t.Errorf("Replay() modified the commands it was given")
+	}
+	if _, _, err := Replay(nil, Command{Op: "shuffle"}); !errors.Is(err, ErrUnknownOp) {
+		t.Errorf("Replay() of an unknown op error = %v, want %v", err, ErrUnknownOp)
+	}
+	if _, err := SetCommand(0, 1).Undo([]int{1}); !errors.Is(err, ErrNotApplied) {
+		t.Errorf("Undo() of a command that was not applied error = %v, want %v", err, ErrNotApplied)
+	}
+}
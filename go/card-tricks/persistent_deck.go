@@ -0,0 +1,264 @@
+package cards
+
+import "iter"
+
+// PersistentDeck is an immutable sequence of cards. Every operation that
+// changes the deck returns a new version and leaves the receiver untouched;
+// the versions share all unchanged structure, so keeping an old version
+// around as an undo snapshot costs nothing extra.
+//
+// The deck is stored as an AVL tree keyed by position. Get and Set cost
+// O(log n), and Insert, Remove, Concat and Split cost O(log n) plus the
+// number of inserted values. The zero value is an empty deck.
+type PersistentDeck[T any] struct {
+	root *deckNode[T]
+}
+
+type deckNode[T any] struct {
+	left, right *deckNode[T]
+	value       T
+	height      int
+	size        int
+}
+
+// NewPersistentDeck returns a deck holding the given values in order.
+func NewPersistentDeck[T any](values ...T) PersistentDeck[T] {
+	return PersistentDeck[T]{root: buildTree(values)}
+}
+
+// Len returns the number of cards in the deck.
+func (d PersistentDeck[T]) Len() int {
+	return treeSize(d.root)
+}
+
+// Get retrieves the card at the given position.
+func (d PersistentDeck[T]) Get(index int) (T, error) {
+	if index < 0 || index >= d.Len() {
+		var zero T
+		return zero, outOfRange(index, d.Len())
+	}
+	n := d.root
+	for {
+		ls := treeSize(n.left)
+		switch {
+		case index < ls:
+			n = n.left
+		case index > ls:
+			index -= ls + 1
+			n = n.right
+		default:
+			return n.value, nil
+		}
+	}
+}
+
+// Set returns a deck with the card at the given position replaced.
+func (d PersistentDeck[T]) Set(index int, value T) (PersistentDeck[T], error) {
+	if index < 0 || index >= d.Len() {
+		return d, outOfRange(index, d.Len())
+	}
+	return PersistentDeck[T]{root: setAt(d.root, index, value)}, nil
+}
+
+// Insert returns a deck with the values added before the given position.
+// An index equal to Len appends the values at the end.
+func (d PersistentDeck[T]) Insert(index int, values ...T) (PersistentDeck[T], error) {
+	if index < 0 || index > d.Len() {
+		return d, outOfRange(index, d.Len())
+	}
+	left, right := splitTree(d.root, index)
+	return PersistentDeck[T]{root: concatTree(concatTree(left, buildTree(values)), right)}, nil
+}
+
+// Prepend returns a deck with the values added at the front.
+func (d PersistentDeck[T]) Prepend(values ...T) PersistentDeck[T] {
+	return PersistentDeck[T]{root: concatTree(buildTree(values), d.root)}
+}
+
+// Append returns a deck with the values added at the back.
+func (d PersistentDeck[T]) Append(values ...T) PersistentDeck[T] {
+	return PersistentDeck[T]{root: concatTree(d.root, buildTree(values))}
+}
+
+// Remove returns a deck without the card at the given position.
+func (d PersistentDeck[T]) Remove(index int) (PersistentDeck[T], error) {
+	if index < 0 || index >= d.Len() {
+		return d, outOfRange(index, d.Len())
+	}
+	left, right := splitTree(d.root, index)
+	_, right = splitTree(right, 1)
+	return PersistentDeck[T]{root: concatTree(left, right)}, nil
+}
+
+// Concat returns a deck holding the cards of d followed by the cards of other.
+func (d PersistentDeck[T]) Concat(other PersistentDeck[T]) PersistentDeck[T] {
+	return PersistentDeck[T]{root: concatTree(d.root, other.root)}
+}
+
+// Split cuts the deck before the given position, returning the cards above
+// and below the cut.
+func (d PersistentDeck[T]) Split(index int) (PersistentDeck[T], PersistentDeck[T], error) {
+	if index < 0 || index > d.Len() {
+		return d, PersistentDeck[T]{}, outOfRange(index, d.Len())
+	}
+	left, right := splitTree(d.root, index)
+	return PersistentDeck[T]{root: left}, PersistentDeck[T]{root: right}, nil
+}
+
+// All returns an iterator over the positions and cards of the deck in order.
+func (d PersistentDeck[T]) All() iter.Seq2[int, T] {
+	return func(yield func(int, T) bool) {
+		i := 0
+		var walk func(n *deckNode[T]) bool
+		walk = func(n *deckNode[T]) bool {
+			if n == nil {
+				return true
+			}
+			if !walk(n.left) || !yield(i, n.value) {
+				return false
+			}
+			i++
+			return walk(n.right)
+		}
+		walk(d.root)
+	}
+}
+
+// Slice copies the cards of the deck into a new slice.
+func (d PersistentDeck[T]) Slice() []T {
+	result := make([]T, 0, d.Len())
+	for _, v := range d.All() {
+		result = append(result, v)
+	}
+	return result
+}
+
+func treeSize[T any](n *deckNode[T]) int {
+	if n == nil {
+		return 0
+	}
+	return n.size
+}
+
+func treeHeight[T any](n *deckNode[T]) int {
+	if n == nil {
+		return 0
+	}
+	return n.height
+}
+
+// newNode allocates a new node; nodes are never modified after creation.
+func newNode[T any](left *deckNode[T], value T, right *deckNode[T]) *deckNode[T] {
+	return &deckNode[T]{
+		left:   left,
+		right:  right,
+		value:  value,
+		height: max(treeHeight(left), treeHeight(right)) + 1,
+		size:   treeSize(left) + treeSize(right) + 1,
+	}
+}
+
+func buildTree[T any](values []T) *deckNode[T] {
+	if len(values) == 0 {
+		return nil
+	}
+	mid := len(values) / 2
+	return newNode(buildTree(values[:mid]), values[mid], buildTree(values[mid+1:]))
+}
+
+func setAt[T any](n *deckNode[T], index int, value T) *deckNode[T] {
+	ls := treeSize(n.left)
+	switch {
+	case index < ls:
+		return newNode(setAt(n.left, index, value), n.value, n.right)
+	case index > ls:
+		return newNode(n.left, n.value, setAt(n.right, index-ls-1, value))
+	default:
+		return newNode(n.left, value, n.right)
+	}
+}
+
+func rotateLeft[T any](n *deckNode[T]) *deckNode[T] {
+	r := n.right
+	return newNode(newNode(n.left, n.value, r.left), r.value, r.right)
+}
+
+func rotateRight[T any](n *deckNode[T]) *deckNode[T] {
+	l := n.left
+	return newNode(l.left, l.value, newNode(l.right, n.value, n.right))
+}
+
+// joinTree builds a balanced tree holding left, value and right in that order.
+func joinTree[T any](left *deckNode[T], value T, right *deckNode[T]) *deckNode[T] {
+	switch {
+	case treeHeight(left) > treeHeight(right)+1:
+		return joinRight(left, value, right)
+	case treeHeight(right) > treeHeight(left)+1:
+		return joinLeft(left, value, right)
+	default:
+		return newNode(left, value, right)
+	}
+}
+
+func joinRight[T any](left *deckNode[T], value T, right *deckNode[T]) *deckNode[T] {
+	if treeHeight(left.right) <= treeHeight(right)+1 {
+		t := newNode(left.right, value, right)
+		if treeHeight(t) <= treeHeight(left.left)+1 {
+			return newNode(left.left, left.value, t)
+		}
+		return rotateLeft(newNode(left.left, left.value, rotateRight(t)))
+	}
+	t := joinRight(left.right, value, right)
+	if treeHeight(t) <= treeHeight(left.left)+1 {
+		return newNode(left.left, left.value, t)
+	}
+	return rotateLeft(newNode(left.left, left.value, t))
+}
+
+func joinLeft[T any](left *deckNode[T], value T, right *deckNode[T]) *deckNode[T] {
+	if treeHeight(right.left) <= treeHeight(left)+1 {
+		t := newNode(left, value, right.left)
+		if treeHeight(t) <= treeHeight(right.right)+1 {
+			return newNode(t, right.value, right.right)
+		}
+		return rotateRight(newNode(rotateLeft(t), right.value, right.right))
+	}
+	t := joinLeft(left, value, right.left)
+	if treeHeight(t) <= treeHeight(right.right)+1 {
+		return newNode(t, right.value, right.right)
+	}
+	return rotateRight(newNode(t, right.value, right.right))
+}
+
+// splitTree returns the first index elements of n and the remaining ones.
+func splitTree[T any](n *deckNode[T], index int) (*deckNode[T], *deckNode[T]) {
+	if n == nil {
+		return nil, nil
+	}
+	ls := treeSize(n.left)
+	if index <= ls {
+		left, right := splitTree(n.left, index)
+		return left, joinTree(right, n.value, n.right)
+	}
+	left, right := splitTree(n.right, index-ls-1)
+	return joinTree(n.left, n.value, left), right
+}
+
+func splitLast[T any](n *deckNode[T]) (*deckNode[T], T) {
+	if n.right == nil {
+		return n.left, n.value
+	}
+	right, last := splitLast(n.right)
+	return joinTree(n.left, n.value, right), last
+}
+
+func concatTree[T any](left, right *deckNode[T]) *deckNode[T] {
+	if left == nil {
+		return right
+	}
+	if right == nil {
+		return left
+	}
+	rest, last := splitLast(left)
+	return joinTree(rest, last, right)
+}
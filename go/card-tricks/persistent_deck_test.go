@@ -0,0 +1,194 @@
+package cards
+
+import (
+	"errors"
+	"math/rand/v2"
+	"testing"
+)
+
+// checkBalanced reports an error if n is not a valid AVL tree with correct sizes.
+func checkBalanced[T any](t *testing.T, n *deckNode[T]) {
+	t.Helper()
+	var walk func(n *deckNode[T]) (height, size int)
+	walk = func(n *deckNode[T]) (int, int) {
+		if n == nil {
+			return 0, 0
+		}
+		lh, ls := walk(n.left)
+		rh, rs := walk(n.right)
+		if lh-rh > 1 || rh-lh > 1 {
+			t.Fatalf("unbalanced node: left height %d, right height %d", lh, rh)
+		}
+		if n.height != max(lh, rh)+1 || n.size != ls+rs+1 {
+			t.Fatalf("stale node: height %d size %d, want %d %d", n.height, n.size, max(lh, rh)+1, ls+rs+1)
+		}
+		return n.height, n.size
+	}
+	walk(n)
+}
+
+func TestPersistentDeckOperations(t *testing.T) {
+	deck := NewPersistentDeck(5, 2, 10, 6)
+
+	inserted, err := deck.Insert(2, 7, 8)
+	if err != nil || !slicesEqual(inserted.Slice(), []int{5, 2, 7, 8, 10, 6}) {
+		t.Errorf("Insert(2, 7, 8) = %v, %v, want [5 2 7 8 10 6], nil", inserted.Slice(), err)
+	}
+	removed, err := inserted.Remove(0)
+	if err != nil || !slicesEqual(removed.Slice(), []int{2, 7, 8, 10, 6}) {
+		t.Errorf("Remove(0) = %v, %v, want [2 7 8 10 6], nil", removed.Slice(), err)
+	}
+	changed, err := removed.Set(4, -1)
+	if err != nil || !slicesEqual(changed.Slice(), []int{2, 7, 8, 10, -1}) {
+		t.Errorf("Set(4, -1) = %v, %v, want [2 7 8 10 -1], nil", changed.Slice(), err)
+	}
+	if got, err := changed.Get(4); err != nil || got != -1 {
+		t.Errorf("Get(4) = %v, %v, want -1, nil", got, err)
+	}
+	prepended := deck.Prepend(1).Append(9)
+	if !slicesEqual(prepended.Slice(), []int{1, 5, 2, 10, 6, 9}) {
+		t.Errorf("Prepend(1).Append(9) = %v, want [1 5 2 10 6 9]", prepended.Slice())
+	}
+	top, bottom, err := prepended.Split(2)
+	if err != nil || !slicesEqual(top.Slice(), []int{1, 5}) || !slicesEqual(bottom.Slice(), []int{2, 10, 6, 9}) {
+		t.Errorf("Split(2) = %v, %v, %v, want [1 5], [2 10 6 9], nil", top.Slice(), bottom.Slice(), err)
+	}
+	if got := bottom.Concat(top); !slicesEqual(got.Slice(), []int{2, 10, 6, 9, 1, 5}) {
+		t.Errorf("Concat() = %v, want [2 10 6 9 1 5]", got.Slice())
+	}
+
+	// Every earlier version is a snapshot that the later edits did not touch.
+	if !slicesEqual(deck.Slice(), []int{5, 2, 10, 6}) {
+		t.Errorf("original deck changed to %v", deck.Slice())
+	}
+	if !slicesEqual(inserted.Slice(), []int{5, 2, 7, 8, 10, 6}) {
+		t.Errorf("inserted deck changed to %v", inserted.Slice())
+	}
+}
+
+func TestPersistentDeckOutOfRange(t *testing.T) {
+	deck := NewPersistentDeck(1, 2, 3)
+	if _, err := deck.Get(3); !errors.Is(err, ErrIndexOutOfRange) {
+		t.Errorf("Get(3) error = %v, want %v", err, ErrIndexOutOfRange)
+	}
+	if _, err := deck.Set(-1, 0); !errors.Is(err, ErrIndexOutOfRange) {
+		t.Errorf("Set(-1, 0) error = %v, want %v", err, ErrIndexOutOfRange)
+	}
+	if _, err := deck.Insert(4, 0); !errors.Is(err, ErrIndexOutOfRange) {
+		t.Errorf("Insert(4, 0) error = %v, want %v", err, ErrIndexOutOfRange)
+	}
+	if _, err := deck.Remove(3); !errors.Is(err, ErrIndexOutOfRange) {
+		t.Errorf("Remove(3) error = %v, want %v", err, ErrIndexOutOfRange)
+	}
+	if _, _, err := deck.Split(4); !errors.Is(err, ErrIndexOutOfRange) {
+		t.Errorf("Split(4) error = %v, want %v", err, ErrIndexOutOfRange)
+	}
+	var empty PersistentDeck[int]
+	if empty.Len() != 0 || len(empty.Slice()) != 0 {
+		t.Errorf("zero PersistentDeck has %d cards, want 0", empty.Len())
+	}
+}
+
+func TestPersistentDeckMatchesSlice(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	deck := NewPersistentDeck[int]()
+	var want []int
+	for i := 0; i < 2000; i++ {
+		switch op := rng.IntN(4); {
+		case op == 0 && len(want) > 0:
+			index := rng.IntN(len(want))
+			deck, _ = deck.Remove(index)
+			want, _ = Remove(want, index)
+		case op == 1:
+			other := NewPersistentDeck(i, -i)
+			deck = deck.Concat(other)
+			want = append(want, i, -i)
+		default:
+			index := rng.IntN(len(want) + 1)
+			deck, _ = deck.Insert(index, i)
+			want, _ = Insert(want, index, i)
+		}
+		checkBalanced(t, deck.root)
+	}
+	if !slicesEqual(deck.Slice(), want) {
+		t.Fatalf("PersistentDeck diverged from slice operations")
+	}
+}
+
+const benchmarkDeckSize = 10000
+
+func benchmarkValues() []int {
+	values := make([]int, benchmarkDeckSize)
+	for i := range values {
+		values[i] = i
+	}
+	return values
+}
+
+func BenchmarkPersistentDeckInsert(b *testing.B) {
+	deck := NewPersistentDeck(benchmarkValues()...)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = deck.Insert(benchmarkDeckSize/2, i)
+	}
+}
+
+func BenchmarkSliceInsert(b *testing.B) {
+	slice := benchmarkValues()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = Insert(slice, benchmarkDeckSize/2, i)
+	}
+}
+
+func BenchmarkPersistentDeckRemove(b *testing.B) {
+	deck := NewPersistentDeck(benchmarkValues()...)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = deck.Remove(benchmarkDeckSize / 2)
+	}
+}
+
+func BenchmarkRemoveItem(b *testing.B) {
+	values := benchmarkValues()
+	slice := make([]int, len(values))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		// RemoveItem modifies its input, so each round needs a fresh copy.
+		copy(slice, values)
+		_ = RemoveItem(slice, benchmarkDeckSize/2)
+	}
+}
+
+func BenchmarkPersistentDeckPrepend(b *testing.B) {
+	deck := NewPersistentDeck(benchmarkValues()...)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = deck.Prepend(i)
+	}
+}
+
+func BenchmarkPrependItems(b *testing.B) {
+	slice := benchmarkValues()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = PrependItems(slice, i)
+	}
+}
+
+func BenchmarkPersistentDeckConcat(b *testing.B) {
+	deck := NewPersistentDeck(benchmarkValues()...)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = deck.Concat(deck)
+	}
+}
+
+func BenchmarkSliceConcat(b *testing.B) {
+	slice := benchmarkValues()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		result := make([]int, 0, 2*len(slice))
+		_ = append(append(result, slice...), slice...)
+	}
+}
@@ -0,0 +1,267 @@
+package cards
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+)
+
+// ErrInvalidPermutation is returned when a permutation does not contain
+// every position from 0 to n-1 exactly once.
+var ErrInvalidPermutation = errors.New("invalid permutation")
+
+// ErrSizeMismatch is returned when a permutation and a deck have different sizes.
+var ErrSizeMismatch = errors.New("size mismatch")
+
+// Permutation describes a deck manipulation. Position 0 is the top of the
+// deck, and after the manipulation the card at position i is the one that
+// was at position p[i] before it.
+type Permutation []int
+
+// Identity returns the permutation that leaves a deck of n cards unchanged.
+func Identity(n int) Permutation {
+	p := make(Permutation, n)
+	for i := range p {
+		p[i] = i
+	}
+	return p
+}
+
+// Validate checks that p is a permutation of the positions 0 to len(p)-1.
+func (p Permutation) Validate() error {
+	seen := make([]bool, len(p))
+	for i, from := range p {
+		if from < 0 || from >= len(p) || seen[from] {
+			return fmt.Errorf("%w: position %d takes card %d", ErrInvalidPermutation, i, from)
+		}
+		seen[from] = true
+	}
+	return nil
+}
+
+// Then returns the permutation that performs p followed by q.
+func (p Permutation) Then(q Permutation) (Permutation, error) {
+	if len(p) != len(q) {
+		return nil, fmt.Errorf("%w: %d and %d cards", ErrSizeMismatch, len(p), len(q))
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+	result := make(Permutation, len(p))
+	for i, from := range q {
+		result[i] = p[from]
+	}
+	return result, nil
+}
+
+// Inverse returns the permutation that undoes p. It panics if p is not
+// valid; see Validate.
+func (p Permutation) Inverse() Permutation {
+	result := make(Permutation, len(p))
+	for i, from := range p {
+		result[from] = i
+	}
+	return result
+}
+
+// Cycles returns the cycles of p, each listing the positions a card visits
+// on repeated application. Fixed points are included as cycles of length one.
+// Like Inverse, it panics if p is not valid.
+func (p Permutation) Cycles() [][]int {
+	inverse := p.Inverse()
+	visited := make([]bool, len(p))
+	var cycles [][]int
+	for start := range p {
+		if visited[start] {
+			continue
+		}
+		var cycle []int
+		for pos := start; !visited[pos]; pos = inverse[pos] {
+			visited[pos] = true
+			cycle = append(cycle, pos)
+		}
+		cycles = append(cycles, cycle)
+	}
+	return cycles
+}
+
+// CycleType returns the cycle lengths of p from longest to shortest.
+func (p Permutation) CycleType() []int {
+	var lengths []int
+	for _, cycle := range p.Cycles() {
+		lengths = append(lengths, len(cycle))
+	}
+	slices.SortFunc(lengths, func(a, b int) int { return b - a })
+	return lengths
+}
+
+// Order returns how many times p has to be repeated to restore the deck.
+func (p Permutation) Order() int {
+	order := 1
+	for _, cycle := range p.Cycles() {
+		order = order / gcd(order, len(cycle)) * len(cycle)
+	}
+	return order
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+// Apply returns a new deck with the cards of deck rearranged by p.
+func Apply[T any](p Permutation, deck []T) ([]T, error) {
+	if len(p) != len(deck) {
+		return nil, fmt.Errorf("%w: permutation of %d cards, deck of %d", ErrSizeMismatch, len(p), len(deck))
+	}
+	result := make([]T, len(deck))
+	for i, from := range p {
+		card, err := Get(deck, from)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = card
+	}
+	return result, nil
+}
+
+// OutFaro returns a perfect out-shuffle of n cards: the deck is cut in half
+// and the halves interleaved so that the top card stays on top. When n is
+// odd the top half holds the extra card.
+func OutFaro(n int) Permutation {
+	return faro(n, (n+1)/2, true)
+}
+
+// InFaro returns a perfect in-shuffle of n cards: the deck is cut in half
+// and the halves interleaved so that the top card moves to second place.
+// When n is odd the bottom half holds the extra card.
+func InFaro(n int) Permutation {
+	return faro(n, n/2, false)
+}
+
+func faro(n, half int, topFirst bool) Permutation {
+	p := make(Permutation, 0, n)
+	top, bottom := 0, half
+	for len(p) < n {
+		if topFirst && top < half || !topFirst && bottom >= n {
+			p = append(p, top)
+			top++
+		} else {
+			p = append(p, bottom)
+			bottom++
+		}
+		topFirst = !topFirst
+	}
+	return p
+}
+
+// Cut returns the permutation that moves the top k of n cards to the bottom.
+func Cut(n, k int) (Permutation, error) {
+	if k < 0 || k > n {
+		return nil, outOfRange(k, n)
+	}
+	p := make(Permutation, n)
+	for i := range p {
+		p[i] = (i + k) % n
+	}
+	return p, nil
+}
+
+// Deal returns the permutation of dealing n cards one at a time onto the
+// given number of piles, then stacking the piles with the first pile on top.
+// Dealing reverses the order of the cards within each pile.
+func Deal(n, piles int) (Permutation, error) {
+	if piles < 1 {
+		return nil, fmt.Errorf("cannot deal into %d piles", piles)
+	}
+	dealt := make([][]int, piles)
+	for i := 0; i < n; i++ {
+		pile := i % piles
+		dealt[pile] = Prepend(dealt[pile], i)
+	}
+	p := make(Permutation, 0, n)
+	for _, pile := range dealt {
+		p = append(p, pile...)
+	}
+	return p, nil
+}
+
+// Riffle returns a random riffle shuffle of n cards following the
+// Gilbert–Shannon–Reeds model: the deck is cut binomially and cards drop
+// from each half with probability proportional to the half's size.
+func Riffle(n int, rng *rand.Rand) Permutation {
+	cut := 0
+	for i := 0; i < n; i++ {
+		if rng.IntN(2) == 0 {
+			cut++
+		}
+	}
+	p := make(Permutation, 0, n)
+	top, bottom := 0, cut
+	for len(p) < n {
+		left, right := cut-top, n-bottom
+		if rng.IntN(left+right) < left {
+			p = append(p, top)
+			top++
+		} else {
+			p = append(p, bottom)
+			bottom++
+		}
+	}
+	return p
+}
+
+// Step is a named manipulation within a trick.
+type Step struct {
+	Name        string
+	Permutation Permutation
+}
+
+// Trick is a sequence of manipulations performed on one deck.
+type Trick []Step
+
+// Permutation composes the steps of the trick into a single permutation.
+func (t Trick) Permutation(n int) (Permutation, error) {
+	result := Identity(n)
+	for _, step := range t {
+		next, err := result.Then(step.Permutation)
+		if err != nil {
+			return nil, fmt.Errorf("step %q: %w", step.Name, err)
+		}
+		result = next
+	}
+	return result, nil
+}
+
+// Track follows named cards through the trick. For every card it returns
+// the card's position before the first step and after each step.
+func Track[T comparable](t Trick, deck []T, cards ...T) (map[T][]int, error) {
+	positions := make(map[T][]int, len(cards))
+	for _, card := range cards {
+		pos := slices.Index(deck, card)
+		if pos < 0 {
+			return nil, fmt.Errorf("card %v is not in the deck", card)
+		}
+		positions[card] = []int{pos}
+	}
+	for _, step := range t {
+		if len(step.Permutation) != len(deck) {
+			return nil, fmt.Errorf("step %q: %w: permutation of %d cards, deck of %d",
+				step.Name, ErrSizeMismatch, len(step.Permutation), len(deck))
+		}
+		if err := step.Permutation.Validate(); err != nil {
+			return nil, fmt.Errorf("step %q: %w", step.Name, err)
+		}
+		inverse := step.Permutation.Inverse()
+		for card, history := range positions {
+			positions[card] = append(history, inverse[history[len(history)-1]])
+		}
+	}
+	return positions, nil
+}
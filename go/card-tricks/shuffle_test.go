@@ -0,0 +1,164 @@
+package cards
+
+import (
+	"errors"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func TestFaroOrder(t *testing.T) {
+	tests := []struct {
+		name string
+		p    Permutation
+		want int
+	}{
+		{name: "8 out-faros restore 52 cards", p: OutFaro(52), want: 8},
+		{name: "52 in-faros restore 52 cards", p: InFaro(52), want: 52},
+		{name: "6 out-faros restore 64 cards", p: OutFaro(64), want: 6},
+		{name: "Identity has order 1", p: Identity(52), want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.p.Validate(); err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+			if got := tt.p.Order(); got != tt.want {
+				t.Errorf("Order() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFaroApply(t *testing.T) {
+	deck := []string{"A", "B", "C", "D", "E", "F"}
+	tests := []struct {
+		name string
+		p    Permutation
+		want []string
+	}{
+		{name: "Out faro", p: OutFaro(6), want: []string{"A", "D", "B", "E", "C", "F"}},
+		{name: "In faro", p: InFaro(6), want: []string{"D", "A", "E", "B", "F", "C"}},
+		{name: "Odd out faro", p: OutFaro(5), want: []string{"A", "D", "B", "E", "C"}},
+		{name: "Odd in faro", p: InFaro(5), want: []string{"C", "A", "D", "B", "E"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Apply(tt.p, deck[:len(tt.p)])
+			if err != nil || !slices.Equal(got, tt.want) {
+				t.Errorf("Apply(%v) = %v, %v, want %v, nil", tt.p, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCutAndDeal(t *testing.T) {
+	cut, err := Cut(5, 2)
+	if err != nil || !slices.Equal(cut, Permutation{2, 3, 4, 0, 1}) {
+		t.Errorf("Cut(5, 2) = %v, %v, want [2 3 4 0 1], nil", cut, err)
+	}
+	if _, err := Cut(5, 6); !errors.Is(err, ErrIndexOutOfRange) {
+		t.Errorf("Cut(5, 6) error = %v, want %v", err, ErrIndexOutOfRange)
+	}
+	deal, err := Deal(7, 3)
+	if err != nil || !slices.Equal(deal, Permutation{6, 3, 0, 4, 1, 5, 2}) {
+		t.Errorf("Deal(7, 3) = %v, %v, want [6 3 0 4 1 5 2], nil", deal, err)
+	}
+	if _, err := Deal(7, 0); err == nil {
+		t.Errorf("Deal(7, 0) error = nil, want an error")
+	}
+}
+
+func TestPermutationAlgebra(t *testing.T) {
+	p := OutFaro(10)
+	q, _ := Cut(10, 3)
+	pq, err := p.Then(q)
+	if err != nil {
+		t.Fatalf("Then() error = %v", err)
+	}
+	deck := FavoriteCards()
+	deck = append(deck, 1, 3, 4, 5, 7, 8, 10)
+	stepwise, _ := Apply(p, deck)
+	stepwise, _ = Apply(q, stepwise)
+	composed, _ := Apply(pq, deck)
+	if !slices.Equal(stepwise, composed) {
+		t.Errorf("Apply(p.Then(q)) = %v, want %v", composed, stepwise)
+	}
+	undone, _ := pq.Then(pq.Inverse())
+	if !slices.Equal(undone, Identity(10)) {
+		t.Errorf("p.Then(p.Inverse()) = %v, want identity", undone)
+	}
+	if _, err := p.Then(Identity(9)); !errors.Is(err, ErrSizeMismatch) {
+		t.Errorf("Then() with different sizes error = %v, want %v", err, ErrSizeMismatch)
+	}
+	if err := (Permutation{0, 0, 1}).Validate(); !errors.Is(err, ErrInvalidPermutation) {
+		t.Errorf("Validate([0 0 1]) error = %v, want %v", err, ErrInvalidPermutation)
+	}
+	if _, err := (Permutation{0, 7}).Then(Identity(2)); !errors.Is(err, ErrInvalidPermutation) {
+		t.Errorf("Then() of [0 7] error = %v, want %v", err, ErrInvalidPermutation)
+	}
+	if _, err := Identity(2).Then(Permutation{0, 7}); !errors.Is(err, ErrInvalidPermutation) {
+		t.Errorf("Then([0 7]) error = %v, want %v", err, ErrInvalidPermutation)
+	}
+}
+
+func TestCycleType(t *testing.T) {
+	p := Permutation{1, 2, 0, 4, 3, 5}
+	if got := p.CycleType(); !slices.Equal(got, []int{3, 2, 1}) {
+		t.Errorf("CycleType() = %v, want [3 2 1]", got)
+	}
+	if got := p.Order(); got != 6 {
+		t.Errorf("Order() = %d, want 6", got)
+	}
+}
+
+func TestRiffle(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	for i := 0; i < 100; i++ {
+		p := Riffle(52, rng)
+		if err := p.Validate(); err != nil {
+			t.Fatalf("Riffle(52) = %v, error %v", p, err)
+		}
+		// A riffle keeps each half in order, so it has at most two rising sequences.
+		rising := 1
+		for pos := 1; pos < 52; pos++ {
+			if p.Inverse()[pos] < p.Inverse()[pos-1] {
+				rising++
+			}
+		}
+		if rising > 2 {
+			t.Fatalf("Riffle(52) = %v has %d rising sequences, want at most 2", p, rising)
+		}
+	}
+}
+
+func TestTrack(t *testing.T) {
+	cut, _ := Cut(6, 2)
+	trick := Trick{
+		{Name: "out faro", Permutation: OutFaro(6)},
+		{Name: "cut two", Permutation: cut},
+	}
+	deck := []string{"AS", "2H", "3C", "4D", "5S", "6H"}
+	got, err := Track(trick, deck, "AS", "4D")
+	if err != nil {
+		t.Fatalf("Track() error = %v", err)
+	}
+	if !slices.Equal(got["AS"], []int{0, 0, 4}) || !slices.Equal(got["4D"], []int{3, 1, 5}) {
+		t.Errorf("Track() = %v, want AS:[0 0 4] 4D:[3 1 5]", got)
+	}
+	p, err := trick.Permutation(6)
+	if err != nil {
+		t.Fatalf("Permutation() error = %v", err)
+	}
+	final, _ := Apply(p, deck)
+	if final[4] != "AS" || final[5] != "4D" {
+		t.Errorf("Apply(trick) = %v, want AS at 4 and 4D at 5", final)
+	}
+	if _, err := Track(trick, deck, "JK"); err == nil {
+		t.Errorf("Track() of a missing card error = nil, want an error")
+	}
+	bad := Trick{{Name: "bad", Permutation: Permutation{0, 1, 2, 3, 4, 7}}}
+	if _, err := Track(bad, deck, "AS"); !errors.Is(err, ErrInvalidPermutation) {
+		t.Errorf("Track() with an invalid step error = %v, want %v", err, ErrInvalidPermutation)
+	}
+}
@@ -12,10 +12,13 @@ func CalculateWorkingCarsPerMinute(productionRate int, successRate float64) int
 	return int(CalculateWorkingCarsPerHour(productionRate, successRate) / 60)
 }
 
-// CalculateCost works out the cost of producing the given number of cars.
+// CalculateCost works out the cost of producing the given number of cars
+// with DefaultCostModel, in whole currency units. It returns 0 for counts
+// that cannot be priced; use CostModel.Calculate to see the error.
 func CalculateCost(carsCount int) uint {
-	groups := uint(carsCount / 10)
-	remainder := uint(carsCount % 10)
-
-	return groups*95000 + remainder*10000
+	breakdown, err := DefaultCostModel.Calculate(carsCount, 0)
+	if err != nil {
+		return 0
+	}
+	return uint(breakdown.Total / 100)
 }
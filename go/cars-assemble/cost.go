@@ -0,0 +1,228 @@
+package cars
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+)
+
+// ErrInvalidInput is returned when a cost model or a car count cannot be priced.
+var ErrInvalidInput = errors.New("invalid input")
+
+// ErrOverflow is returned when a cost does not fit in Money.
+var ErrOverflow = errors.New("cost overflows")
+
+// ErrNoBundling is returned when the tiers cannot add up to the exact number of cars.
+var ErrNoBundling = errors.New("no combination of tiers matches the car count")
+
+// Money is an exact amount in minor currency units, e.g. cents.
+type Money int64
+
+// String formats m in major units with two decimals.
+func (m Money) String() string {
+	sign := ""
+	v := uint64(m)
+	if m < 0 {
+		sign = "-"
+		v = -v
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, v/100, v%100)
+}
+
+// MaxTierSize is the largest bundle a tier may have. Bundling searches
+// over up to MaxTierSize² cars, so this bounds its time and memory.
+const MaxTierSize = 1000
+
+// Tier prices a bundle of Size cars at Price.
+type Tier struct {
+	Size  int
+	Price Money
+}
+
+// CostModel describes how production is priced: cars are bought in tier
+// bundles, plus a one-off setup cost and an overhead for every line used.
+type CostModel struct {
+	Tiers        []Tier
+	SetupCost    Money
+	LineOverhead Money
+}
+
+// DefaultCostModel is the pricing used by CalculateCost: 95,000 per group
+// of ten cars and 10,000 per single car.
+var DefaultCostModel = CostModel{
+	Tiers: []Tier{
+		{Size: 10, Price: 95000_00},
+		{Size: 1, Price: 10000_00},
+	},
+}
+
+// LineItem is one entry of a cost breakdown.
+type LineItem struct {
+	Description string `json:"description"`
+	Quantity    int    `json:"quantity"`
+	UnitPrice   Money  `json:"unit_price"`
+	Total       Money  `json:"total"`
+}
+
+// Breakdown lists what a production run costs.
+type Breakdown struct {
+	Items []LineItem `json:"items"`
+	Total Money      `json:"total"`
+}
+
+// Validate checks that every tier has a positive size of at most
+// MaxTierSize and no cost is negative.
+func (m CostModel) Validate() error {
+	if len(m.Tiers) == 0 {
+		return fmt.Errorf("%w: no tiers", ErrInvalidInput)
+	}
+	for _, tier := range m.Tiers {
+		if tier.Size <= 0 || tier.Size > MaxTierSize || tier.Price < 0 {
+			return fmt.Errorf("%w: tier of %d cars at %v", ErrInvalidInput, tier.Size, tier.Price)
+		}
+	}
+	if m.SetupCost < 0 || m.LineOverhead < 0 {
+		return fmt.Errorf("%w: negative fixed cost", ErrInvalidInput)
+	}
+	return nil
+}
+
+// Calculate works out the cheapest way of producing carsCount cars on the
+// given number of lines. The setup cost and line overhead are always charged.
+func (m CostModel) Calculate(carsCount, lines int) (Breakdown, error) {
+	if err := m.Validate(); err != nil {
+		return Breakdown{}, err
+	}
+	if carsCount < 0 || lines < 0 {
+		return Breakdown{}, fmt.Errorf("%w: %d cars on %d lines", ErrInvalidInput, carsCount, lines)
+	}
+	counts, err := m.bundle(carsCount)
+	if err != nil {
+		return Breakdown{}, err
+	}
+
+	var b Breakdown
+	for i, tier := range m.Tiers {
+		if counts[i] > 0 {
+			if err := b.add(fmt.Sprintf("bundle of %d cars", tier.Size), counts[i], tier.Price); err != nil {
+				return Breakdown{}, err
+			}
+		}
+	}
+	if m.SetupCost > 0 {
+		if err := b.add("setup", 1, m.SetupCost); err != nil {
+			return Breakdown{}, err
+		}
+	}
+	if m.LineOverhead > 0 && lines > 0 {
+		if err := b.add("line overhead", lines, m.LineOverhead); err != nil {
+			return Breakdown{}, err
+		}
+	}
+	return b, nil
+}
+
+func (b *Breakdown) add(description string, quantity int, unitPrice Money) error {
+	total, ok := mulMoney(unitPrice, quantity)
+	if !ok {
+		return fmt.Errorf("%w: %d × %v", ErrOverflow, quantity, unitPrice)
+	}
+	sum, ok := addMoney(b.Total, total)
+	if !ok {
+		return fmt.Errorf("%w: %v + %v", ErrOverflow, b.Total, total)
+	}
+	b.Items = append(b.Items, LineItem{Description: description, Quantity: quantity, UnitPrice: unitPrice, Total: total})
+	b.Total = sum
+	return nil
+}
+
+// bundle returns how many bundles of each tier make up carsCount at the lowest price.
+//
+// Only a bounded remainder needs a full search: if an optimal solution used
+// best.Size or more bundles from other tiers, some of them would add up to a
+// multiple of best.Size and could be swapped for best-value bundles at no
+// extra cost. So everything above best.Size*maxSize cars goes into
+// best-value bundles, and the rest is solved exactly.
+func (m CostModel) bundle(carsCount int) ([]int, error) {
+	best, maxSize := 0, 0
+	for i, tier := range m.Tiers {
+		c := comparePerCar(tier, m.Tiers[best])
+		if c < 0 || c == 0 && tier.Size > m.Tiers[best].Size {
+			best = i
+		}
+		maxSize = max(maxSize, tier.Size)
+	}
+
+	counts := make([]int, len(m.Tiers))
+	bestSize := m.Tiers[best].Size
+	// Validate caps the sizes well below this; check anyway before multiplying.
+	if bestSize > math.MaxInt/maxSize {
+		return nil, fmt.Errorf("%w: tiers of %d and %d cars", ErrOverflow, bestSize, maxSize)
+	}
+	if bound := bestSize * maxSize; carsCount > bound {
+		counts[best] = (carsCount - bound) / bestSize
+		carsCount -= counts[best] * bestSize
+	}
+
+	const none = -1
+	cost := make([]Money, carsCount+1)
+	choice := make([]int, carsCount+1)
+	overflowed := false
+	for n := 1; n <= carsCount; n++ {
+		choice[n] = none
+		for i, tier := range m.Tiers {
+			if tier.Size > n || choice[n-tier.Size] == none {
+				continue
+			}
+			c, ok := addMoney(cost[n-tier.Size], tier.Price)
+			if !ok {
+				overflowed = true
+				continue
+			}
+			if choice[n] == none || c < cost[n] {
+				cost[n], choice[n] = c, i
+			}
+		}
+	}
+	if choice[carsCount] == none && carsCount > 0 {
+		if overflowed {
+			return nil, fmt.Errorf("%w: bundling %d cars", ErrOverflow, carsCount)
+		}
+		return nil, fmt.Errorf("%w: %d cars", ErrNoBundling, carsCount)
+	}
+	for n := carsCount; n > 0; n -= m.Tiers[choice[n]].Size {
+		counts[choice[n]]++
+	}
+	return counts, nil
+}
+
+// comparePerCar compares the price per car of two validated tiers without
+// dividing, using 128-bit products so that it stays exact.
+func comparePerCar(a, b Tier) int {
+	ahi, alo := bits.Mul64(uint64(a.Price), uint64(b.Size))
+	bhi, blo := bits.Mul64(uint64(b.Price), uint64(a.Size))
+	if ahi != bhi {
+		return cmp.Compare(ahi, bhi)
+	}
+	return cmp.Compare(alo, blo)
+}
+
+func addMoney(a, b Money) (Money, bool) {
+	sum := a + b
+	if (b > 0 && sum < a) || (b < 0 && sum > a) {
+		return 0, false
+	}
+	return sum, true
+}
+
+func mulMoney(a Money, n int) (Money, bool) {
+	if a == 0 || n == 0 {
+		return 0, true
+	}
+	if n < 0 || a > math.MaxInt64/Money(n) || a < math.MinInt64/Money(n) {
+		return 0, false
+	}
+	return a * Money(n), true
+}
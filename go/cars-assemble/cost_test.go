@@ -0,0 +1,145 @@
+package cars
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestCostModelCalculate(t *testing.T) {
+	tiered := CostModel{
+		Tiers: []Tier{
+			{Size: 1, Price: 100_00},
+			{Size: 4, Price: 360_00},
+			{Size: 7, Price: 600_00},
+		},
+		SetupCost:    50_00,
+		LineOverhead: 20_00,
+	}
+	tests := []struct {
+		name      string
+		model     CostModel
+		carsCount int
+		lines     int
+		want      Money
+		wantItems int
+	}{
+		{
+			name:      "default model matches CalculateCost",
+			model:     DefaultCostModel,
+			carsCount: 148,
+			want:      1410000_00,
+			wantItems: 2,
+		},
+		{
+			name:      "optimal bundling beats greedy",
+			model:     CostModel{Tiers: []Tier{{Size: 1, Price: 10_00}, {Size: 3, Price: 20_00}, {Size: 4, Price: 26_00}}},
+			carsCount: 6,
+			want:      40_00,
+			wantItems: 1,
+		},
+		{
+			name:      "fixed costs are added",
+			model:     tiered,
+			carsCount: 8,
+			lines:     3,
+			want:      600_00 + 100_00 + 50_00 + 3*20_00,
+			wantItems: 4,
+		},
+		{
+			name:      "large counts use the best-value tier",
+			model:     tiered,
+			carsCount: 7_000_003,
+			lines:     1,
+			want:      600_00*1_000_000 + 300_00 + 50_00 + 20_00,
+			wantItems: 4,
+		},
+		{
+			name:      "zero cars only pays fixed costs",
+			model:     tiered,
+			carsCount: 0,
+			lines:     1,
+			want:      70_00,
+			wantItems: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.model.Calculate(tt.carsCount, tt.lines)
+			if err != nil {
+				t.Fatalf("Calculate(%d, %d) error = %v", tt.carsCount, tt.lines, err)
+			}
+			if got.Total != tt.want || len(got.Items) != tt.wantItems {
+				t.Errorf("Calculate(%d, %d) = %v in %d items, want %v in %d items",
+					tt.carsCount, tt.lines, got.Total, len(got.Items), tt.want, tt.wantItems)
+			}
+			var sum Money
+			for _, item := range got.Items {
+				if item.Total != item.UnitPrice*Money(item.Quantity) {
+					t.Errorf("line item %+v does not add up", item)
+				}
+				sum += item.Total
+			}
+			if sum != got.Total {
+				t.Errorf("line items add up to %v, total is %v", sum, got.Total)
+			}
+		})
+	}
+}
+
+func TestCostModelErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		model     CostModel
+		carsCount int
+		lines     int
+		want      error
+	}{
+		{name: "negative cars", model: DefaultCostModel, carsCount: -1, want: ErrInvalidInput},
+		{name: "negative lines", model: DefaultCostModel, carsCount: 1, lines: -1, want: ErrInvalidInput},
+		{name: "no tiers", model: CostModel{}, carsCount: 1, want: ErrInvalidInput},
+		{name: "empty tier", model: CostModel{Tiers: []Tier{{Size: 0, Price: 1}}}, carsCount: 1, want: ErrInvalidInput},
+		{name: "huge tier", model: CostModel{Tiers: []Tier{{Size: math.MaxInt / 2, Price: 1}, {Size: 1, Price: 1}}}, carsCount: 1, want: ErrInvalidInput},
+		{name: "tier above the limit", model: CostModel{Tiers: []Tier{{Size: MaxTierSize + 1, Price: 1}}}, carsCount: 1, want: ErrInvalidInput},
+		{name: "negative price", model: CostModel{Tiers: []Tier{{Size: 1, Price: -1}}}, carsCount: 1, want: ErrInvalidInput},
+		{name: "count cannot be bundled", model: CostModel{Tiers: []Tier{{Size: 5, Price: 1}}}, carsCount: 7, want: ErrNoBundling},
+		{
+			name:      "total overflows",
+			model:     CostModel{Tiers: []Tier{{Size: 1, Price: math.MaxInt64 / 2}}},
+			carsCount: 3,
+			want:      ErrOverflow,
+		},
+		{
+			name:      "overhead overflows",
+			model:     CostModel{Tiers: []Tier{{Size: 1, Price: 1}}, LineOverhead: math.MaxInt64 / 2},
+			carsCount: 1,
+			lines:     3,
+			want:      ErrOverflow,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.model.Calculate(tt.carsCount, tt.lines); !errors.Is(err, tt.want) {
+				t.Errorf("Calculate(%d, %d) error = %v, want %v", tt.carsCount, tt.lines, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	tests := []struct {
+		money Money
+		want  string
+	}{
+		{money: 0, want: "0.00"},
+		{money: 5, want: "0.05"},
+		{money: 95000_00, want: "95000.00"},
+		{money: -1234, want: "-12.34"},
+		{money: math.MinInt64, want: "-92233720368547758.08"},
+	}
+	for _, tt := range tests {
+		if got := tt.money.String(); got != tt.want {
+			t.Errorf("Money(%d).String() = %q, want %q", int64(tt.money), got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,305 @@
+package cars
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrAlreadyRunning is returned when a pipeline is run a second time.
+var ErrAlreadyRunning = errors.New("pipeline is already running")
+
+// Clock is the source of time for a pipeline, so tests can replace it.
+type Clock interface {
+	After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// Car is a car moving through a pipeline.
+type Car struct {
+	ID int
+	// FailedAt names the stage that found the car defective, if any.
+	FailedAt string
+	// Reworked is set when the car was repaired in the rework queue.
+	Reworked bool
+}
+
+// StageConfig describes one production stage of a pipeline.
+type StageConfig struct {
+	Name string
+	// ProductionRate is how many cars per hour each worker handles.
+	ProductionRate int
+	// SuccessRate is the percentage of cars that leave the stage working.
+	SuccessRate float64
+	Workers     int
+	// Buffer is the capacity of the channel feeding the stage.
+	Buffer int
+}
+
+// ReworkConfig describes the queue that repairs defective cars. Repaired
+// cars join the pipeline output; cars that fail rework are scrapped.
+type ReworkConfig struct {
+	Workers     int
+	Buffer      int
+	Time        time.Duration
+	SuccessRate float64
+}
+
+// PipelineConfig describes a pipeline. Cars is the number of cars to feed
+// in; zero keeps feeding until the context is cancelled. A nil Clock uses
+// the system clock.
+type PipelineConfig struct {
+	Stages []StageConfig
+	Rework ReworkConfig
+	Cars   int
+	Seed   uint64
+	Clock  Clock
+}
+
+// StandardStages returns body, paint, assembly and QA stages with one
+// worker each, running at the given rate and success rate.
+func StandardStages(productionRate int, successRate float64) []StageConfig {
+	var stages []StageConfig
+	for _, name := range []string{"body", "paint", "assembly", "qa"} {
+		stages = append(stages, StageConfig{
+			Name:           name,
+			ProductionRate: productionRate,
+			SuccessRate:    successRate,
+			Workers:        1,
+			Buffer:         1,
+		})
+	}
+	return stages
+}
+
+// StageMetrics is a snapshot of what a stage has done so far.
+type StageMetrics struct {
+	Name string
+	// In counts cars the stage took from its input.
+	In int64
+	// Passed counts cars the stage sent on working.
+	Passed int64
+	// Defective counts cars sent to rework, or scrapped by the rework stage.
+	Defective int64
+	// Drained counts cars dropped because the pipeline was cancelled.
+	Drained int64
+	// Queued is the number of cars waiting in front of the stage.
+	Queued int
+	// Busy is the total time workers spent on cars.
+	Busy time.Duration
+	// ExpectedPerHour is CalculateWorkingCarsPerHour for the stage's workers.
+	ExpectedPerHour float64
+}
+
+type stageCounters struct {
+	in, passed, defective, drained, busy atomic.Int64
+}
+
+// Pipeline runs production stages as goroutines connected by bounded
+// channels. Defective cars go to a rework queue instead of the next stage.
+type Pipeline struct {
+	cfg      PipelineConfig
+	started  atomic.Bool
+	inputs   []chan Car
+	rework   chan Car
+	counters []stageCounters // one per stage, then rework
+	fed      atomic.Int64
+}
+
+// NewPipeline validates cfg and prepares a pipeline.
+func NewPipeline(cfg PipelineConfig) (*Pipeline, error) {
+	if len(cfg.Stages) == 0 || cfg.Cars < 0 || cfg.Rework.Workers <= 0 || cfg.Rework.Buffer < 0 ||
+		cfg.Rework.Time < 0 || cfg.Rework.SuccessRate < 0 || cfg.Rework.SuccessRate > 100 {
+		return nil, fmt.Errorf("%w: pipeline %+v", ErrInvalidInput, cfg)
+	}
+	for _, s := range cfg.Stages {
+		if s.ProductionRate <= 0 || s.Workers <= 0 || s.Buffer < 0 || s.SuccessRate < 0 || s.SuccessRate > 100 {
+			return nil, fmt.Errorf("%w: stage %q", ErrInvalidInput, s.Name)
+		}
+	}
+	if cfg.Clock == nil {
+		cfg.Clock = realClock{}
+	}
+	p := &Pipeline{
+		cfg:      cfg,
+		rework:   make(chan Car, cfg.Rework.Buffer),
+		counters: make([]stageCounters, len(cfg.Stages)+1),
+	}
+	for _, s := range cfg.Stages {
+		p.inputs = append(p.inputs, make(chan Car, s.Buffer))
+	}
+	return p, nil
+}
+
+// Run starts the pipeline and returns the channel of finished cars. The
+// channel is closed once every car has left the pipeline. Cancelling ctx
+// stops the feed; cars still inside are drained and counted, not delivered.
+func (p *Pipeline) Run(ctx context.Context) (<-chan Car, error) {
+	if !p.started.CompareAndSwap(false, true) {
+		return nil, ErrAlreadyRunning
+	}
+	out := make(chan Car)
+	var stagesDone, reworkDone sync.WaitGroup
+
+	go p.feed(ctx)
+	for i, s := range p.cfg.Stages {
+		next := out
+		if i+1 < len(p.cfg.Stages) {
+			next = p.inputs[i+1]
+		}
+		var workers sync.WaitGroup
+		workers.Add(s.Workers)
+		stagesDone.Add(s.Workers)
+		for w := 0; w < s.Workers; w++ {
+			rng := rand.New(rand.NewPCG(p.cfg.Seed, uint64(i)<<32|uint64(w)))
+			go func() {
+				defer stagesDone.Done()
+				defer workers.Done()
+				p.work(ctx, i, rng, next)
+			}()
+		}
+		if next != out {
+			// The next stage's input closes once every worker of this stage is done.
+			go func() {
+				workers.Wait()
+				close(next)
+			}()
+		}
+	}
+
+	reworkDone.Add(p.cfg.Rework.Workers)
+	for w := 0; w < p.cfg.Rework.Workers; w++ {
+		rng := rand.New(rand.NewPCG(p.cfg.Seed, uint64(len(p.cfg.Stages))<<32|uint64(w)))
+		go func() {
+			defer reworkDone.Done()
+			p.repair(ctx, rng, out)
+		}()
+	}
+	go func() {
+		stagesDone.Wait()
+		close(p.rework)
+		reworkDone.Wait()
+		close(out)
+	}()
+	return out, nil
+}
+
+func (p *Pipeline) feed(ctx context.Context) {
+	defer close(p.inputs[0])
+	for id := 1; p.cfg.Cars == 0 || id <= p.cfg.Cars; id++ {
+		select {
+		case p.inputs[0] <- Car{ID: id}:
+			p.fed.Add(1)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (p *Pipeline) work(ctx context.Context, stage int, rng *rand.Rand, next chan<- Car) {
+	s := p.cfg.Stages[stage]
+	c := &p.counters[stage]
+	d := time.Hour / time.Duration(s.ProductionRate)
+	for car := range p.inputs[stage] {
+		c.in.Add(1)
+		if !p.wait(ctx, d, c) {
+			continue
+		}
+		if rng.Float64()*100 < s.SuccessRate {
+			if p.send(ctx, next, car, c) {
+				c.passed.Add(1)
+			}
+			continue
+		}
+		car.FailedAt = s.Name
+		if p.send(ctx, p.rework, car, c) {
+			c.defective.Add(1)
+		}
+	}
+}
+
+func (p *Pipeline) repair(ctx context.Context, rng *rand.Rand, out chan<- Car) {
+	c := &p.counters[len(p.cfg.Stages)]
+	for car := range p.rework {
+		c.in.Add(1)
+		if !p.wait(ctx, p.cfg.Rework.Time, c) {
+			continue
+		}
+		if rng.Float64()*100 >= p.cfg.Rework.SuccessRate {
+			c.defective.Add(1)
+			continue
+		}
+		car.Reworked = true
+		if p.send(ctx, out, car, c) {
+			c.passed.Add(1)
+		}
+	}
+}
+
+// wait spends d working on a car. It reports false, and counts the car as
+// drained, if the pipeline is cancelled first.
+func (p *Pipeline) wait(ctx context.Context, d time.Duration, c *stageCounters) bool {
+	if ctx.Err() != nil {
+		c.drained.Add(1)
+		return false
+	}
+	select {
+	case <-p.cfg.Clock.After(d):
+		c.busy.Add(int64(d))
+		return true
+	case <-ctx.Done():
+		c.drained.Add(1)
+		return false
+	}
+}
+
+func (p *Pipeline) send(ctx context.Context, to chan<- Car, car Car, c *stageCounters) bool {
+	select {
+	case to <- car:
+		return true
+	case <-ctx.Done():
+		c.drained.Add(1)
+		return false
+	}
+}
+
+// Fed returns how many cars have entered the pipeline.
+func (p *Pipeline) Fed() int64 {
+	return p.fed.Load()
+}
+
+// Metrics returns a snapshot of every stage, followed by the rework stage.
+// It is safe to call while the pipeline runs.
+func (p *Pipeline) Metrics() []StageMetrics {
+	metrics := make([]StageMetrics, 0, len(p.counters))
+	for i := range p.counters {
+		c := &p.counters[i]
+		m := StageMetrics{
+			Name:      "rework",
+			In:        c.in.Load(),
+			Passed:    c.passed.Load(),
+			Defective: c.defective.Load(),
+			Drained:   c.drained.Load(),
+			Busy:      time.Duration(c.busy.Load()),
+			Queued:    len(p.rework),
+		}
+		if i < len(p.cfg.Stages) {
+			s := p.cfg.Stages[i]
+			m.Name = s.Name
+			m.Queued = len(p.inputs[i])
+			m.ExpectedPerHour = CalculateWorkingCarsPerHour(s.ProductionRate*s.Workers, s.SuccessRate)
+		} else if p.cfg.Rework.Time > 0 {
+			m.ExpectedPerHour = CalculateWorkingCarsPerHour(
+				int(time.Hour/p.cfg.Rework.Time)*p.cfg.Rework.Workers, p.cfg.Rework.SuccessRate)
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics
+}
@@ -0,0 +1,203 @@
+package cars
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock only lets time pass when the test advances it.
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Duration
+	waiters []fakeWaiter
+}
+
+type fakeWaiter struct {
+	at time.Duration
+	ch chan time.Time
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	c.waiters = append(c.waiters, fakeWaiter{at: c.now + d, ch: ch})
+	return ch
+}
+
+// Advance moves the clock forward and fires every waiter that is due.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now += d
+	pending := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.at <= c.now {
+			w.ch <- time.Unix(0, int64(w.at))
+		} else {
+			pending = append(pending, w)
+		}
+	}
+	c.waiters = pending
+}
+
+// collect reads out until it closes, advancing the clock whenever the
+// pipeline is waiting on it.
+func collect(t *testing.T, clock *fakeClock, out <-chan Car) []Car {
+	t.Helper()
+	var cars []Car
+	deadline := time.After(10 * time.Second)
+	for {
+		select {
+		case car, ok := <-out:
+			if !ok {
+				return cars
+			}
+			cars = append(cars, car)
+		case <-deadline:
+			t.Fatalf("pipeline did not finish, %d cars out", len(cars))
+		default:
+			clock.Advance(time.Minute)
+			runtime.Gosched()
+		}
+	}
+}
+
+func TestPipelineRun(t *testing.T) {
+	clock := &fakeClock{}
+	stages := StandardStages(60, 100)
+	stages[3].SuccessRate = 50
+	stages[1].Workers = 3
+	p, err := NewPipeline(PipelineConfig{
+		Stages: stages,
+		Rework: ReworkConfig{Workers: 2, Buffer: 4, Time: 5 * time.Minute, SuccessRate: 100},
+		Cars:   40,
+		Seed:   3,
+		Clock:  clock,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	out, err := p.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	cars := collect(t, clock, out)
+
+	if len(cars) != 40 {
+		t.Fatalf("Run() delivered %d cars, want 40", len(cars))
+	}
+	reworked := 0
+	for _, car := range cars {
+		if car.Reworked {
+			reworked++
+			if car.FailedAt != "qa" {
+				t.Errorf("car %d failed at %q, only qa rejects cars", car.ID, car.FailedAt)
+			}
+		}
+	}
+	metrics := p.Metrics()
+	if len(metrics) != 5 || metrics[4].Name != "rework" {
+		t.Fatalf("Metrics() = %+v, want four stages and rework", metrics)
+	}
+	qa, rework := metrics[3], metrics[4]
+	if qa.In != 40 || qa.Passed+qa.Defective != 40 || qa.Defective == 0 {
+		t.Errorf("qa metrics = %+v, want 40 cars in, split between passed and defective", qa)
+	}
+	if rework.Passed != int64(reworked) || rework.In != qa.Defective {
+		t.Errorf("rework metrics = %+v, want %d in and %d passed", rework, qa.Defective, reworked)
+	}
+	if metrics[0].Busy != 40*time.Minute {
+		t.Errorf("body busy for %v, want 40m", metrics[0].Busy)
+	}
+	if metrics[1].ExpectedPerHour != 180 {
+		t.Errorf("paint expected %v cars per hour, want 180", metrics[1].ExpectedPerHour)
+	}
+	if _, err := p.Run(context.Background()); !errors.Is(err, ErrAlreadyRunning) {
+		t.Errorf("second Run() error = %v, want %v", err, ErrAlreadyRunning)
+	}
+}
+
+func TestPipelineCancelDrains(t *testing.T) {
+	before := runtime.NumGoroutine()
+	clock := &fakeClock{}
+	p, err := NewPipeline(PipelineConfig{
+		Stages: StandardStages(60, 90),
+		Rework: ReworkConfig{Workers: 1, Buffer: 1, Time: time.Minute, SuccessRate: 50},
+		Seed:   1,
+		Clock:  clock,
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	out, err := p.Run(ctx)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	// Let some cars through, then cancel while the line is busy.
+	delivered := 0
+	for delivered < 5 {
+		select {
+		case <-out:
+			delivered++
+		default:
+			clock.Advance(time.Minute)
+			runtime.Gosched()
+		}
+	}
+	// Once a car waits in front of the first stage, cancelling has to drain it.
+	for p.Metrics()[0].Queued == 0 {
+		runtime.Gosched()
+	}
+	cancel()
+	for range out {
+		delivered++
+	}
+
+	var finished, scrapped, drained int64
+	for _, m := range p.Metrics() {
+		drained += m.Drained
+		if m.Name == "rework" {
+			finished += m.Passed
+			scrapped += m.Defective
+		}
+	}
+	finished += p.Metrics()[3].Passed
+	if int64(delivered) != finished {
+		t.Errorf("delivered %d cars, metrics count %d", delivered, finished)
+	}
+	if drained == 0 {
+		t.Errorf("cancelling a busy pipeline drained no cars")
+	}
+	if p.Fed() != finished+scrapped+drained {
+		t.Errorf("fed %d cars, but %d finished, %d scrapped and %d drained", p.Fed(), finished, scrapped, drained)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("%d goroutines still running after cancellation, %d before Run", after, before)
+	}
+}
+
+func TestNewPipelineInvalidInput(t *testing.T) {
+	rework := ReworkConfig{Workers: 1, SuccessRate: 100}
+	for _, cfg := range []PipelineConfig{
+		{Rework: rework},
+		{Stages: StandardStages(0, 100), Rework: rework},
+		{Stages: StandardStages(10, 100)},
+		{Stages: []StageConfig{{Name: "body", ProductionRate: 10, SuccessRate: 100}}, Rework: rework},
+	} {
+		if _, err := NewPipeline(cfg); !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("NewPipeline(%+v) error = %v, want %v", cfg, err, ErrInvalidInput)
+		}
+	}
+}
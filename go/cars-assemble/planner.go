@@ -0,0 +1,201 @@
+package cars
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
+// ErrInfeasible is matched by every InfeasibleError.
+var ErrInfeasible = errors.New("plan is infeasible")
+
+// Constraint names a limit that a plan has to respect.
+type Constraint string
+
+const (
+	ConstraintSuccessRate    Constraint = "success rate"
+	ConstraintProductionRate Constraint = "production rate"
+	ConstraintLines          Constraint = "lines"
+	ConstraintHours          Constraint = "hours"
+	ConstraintBudget         Constraint = "budget"
+)
+
+// InfeasibleError reports the constraint that rules out every plan,
+// together with what the target would need and what the limit allows.
+// For ConstraintSuccessRate, these are the working cars wanted and the
+// working cars a 0% success rate yields.
+type InfeasibleError struct {
+	Constraint Constraint
+	Required   float64
+	Limit      float64
+}
+
+func (e *InfeasibleError) Error() string {
+	return fmt.Sprintf("%v: %s needs %v, limit is %v", ErrInfeasible, e.Constraint,
+		strconv.FormatFloat(e.Required, 'f', -1, 64), strconv.FormatFloat(e.Limit, 'f', -1, 64))
+}
+
+// Is makes errors.Is(err, ErrInfeasible) match.
+func (e *InfeasibleError) Is(target error) bool {
+	return target == ErrInfeasible
+}
+
+// PlanRequest describes a production target. Exactly one of
+// ProductionRate, Lines and Hours must be zero; that is the value the
+// planner solves for. Zero limits and budget mean unlimited, negative ones
+// are invalid, and a zero CostModel means DefaultCostModel. A given value
+// over its limit is invalid too.
+type PlanRequest struct {
+	// Target is the number of working cars needed.
+	Target      int
+	SuccessRate float64
+	// ProductionRate is the number of cars per hour of each line.
+	ProductionRate int
+	Lines          int
+	Hours          float64
+
+	MaxProductionRate int
+	MaxLines          int
+	MaxHours          float64
+	Budget            Money
+	CostModel         CostModel
+}
+
+// Plan is a way of reaching a production target.
+type Plan struct {
+	ProductionRate int     `json:"production_rate"`
+	Lines          int     `json:"lines"`
+	Hours          float64 `json:"hours"`
+	// CarsBuilt is the number of cars that have to be built, defects
+	// included, to end up with the target number of working cars.
+	CarsBuilt int `json:"cars_built"`
+	// WorkingCars is the number of working cars the plan produces.
+	WorkingCars float64   `json:"working_cars"`
+	Cost        Money     `json:"cost"`
+	Breakdown   Breakdown `json:"breakdown"`
+}
+
+// SolvePlan works backwards from a production target to the missing
+// production rate, number of lines or number of hours. It returns an
+// *InfeasibleError when a limit or the budget cannot be met.
+func SolvePlan(req PlanRequest) (Plan, error) {
+	unknowns := 0
+	for _, missing := range []bool{req.ProductionRate == 0, req.Lines == 0, req.Hours == 0} {
+		if missing {
+			unknowns++
+		}
+	}
+	if unknowns != 1 || req.Target < 0 || req.ProductionRate < 0 || req.Lines < 0 || req.Hours < 0 ||
+		req.SuccessRate < 0 || req.SuccessRate > 100 ||
+		req.MaxProductionRate < 0 || req.MaxLines < 0 || req.MaxHours < 0 || req.Budget < 0 ||
+		!finite(req.Hours) || !finite(req.SuccessRate) || !finite(req.MaxHours) {
+		return Plan{}, fmt.Errorf("%w: %+v", ErrInvalidInput, req)
+	}
+	// The values given must respect their limits as well.
+	if req.MaxProductionRate > 0 && req.ProductionRate > req.MaxProductionRate ||
+		req.MaxLines > 0 && req.Lines > req.MaxLines ||
+		req.MaxHours > 0 && req.Hours > req.MaxHours {
+		return Plan{}, fmt.Errorf("%w: a given value is over its limit: %+v", ErrInvalidInput, req)
+	}
+	if req.SuccessRate == 0 {
+		if req.Target == 0 {
+			return Plan{}, fmt.Errorf("%w: a 0%% success rate cannot be planned for", ErrInvalidInput)
+		}
+		return Plan{}, &InfeasibleError{Constraint: ConstraintSuccessRate, Required: float64(req.Target), Limit: 0}
+	}
+
+	target := float64(req.Target)
+	p := Plan{ProductionRate: req.ProductionRate, Lines: req.Lines, Hours: req.Hours}
+	var err error
+	switch {
+	case req.Hours == 0:
+		p.Hours = target / (CalculateWorkingCarsPerHour(p.ProductionRate, req.SuccessRate) * float64(p.Lines))
+		if req.MaxHours > 0 && p.Hours > req.MaxHours {
+			return Plan{}, &InfeasibleError{Constraint: ConstraintHours, Required: p.Hours, Limit: req.MaxHours}
+		}
+	case req.ProductionRate == 0:
+		p.ProductionRate, err = ceil(target*100/req.SuccessRate/(float64(p.Lines)*p.Hours), ConstraintProductionRate)
+		if err != nil {
+			return Plan{}, err
+		}
+		if req.MaxProductionRate > 0 && p.ProductionRate > req.MaxProductionRate {
+			return Plan{}, &InfeasibleError{Constraint: ConstraintProductionRate,
+				Required: float64(p.ProductionRate), Limit: float64(req.MaxProductionRate)}
+		}
+	default:
+		p.Lines, err = ceil(target/(CalculateWorkingCarsPerHour(p.ProductionRate, req.SuccessRate)*p.Hours), ConstraintLines)
+		if err != nil {
+			return Plan{}, err
+		}
+		if req.MaxLines > 0 && p.Lines > req.MaxLines {
+			return Plan{}, &InfeasibleError{Constraint: ConstraintLines,
+				Required: float64(p.Lines), Limit: float64(req.MaxLines)}
+		}
+	}
+	p.WorkingCars = CalculateWorkingCarsPerHour(p.ProductionRate, req.SuccessRate) * float64(p.Lines) * p.Hours
+	p.CarsBuilt, err = ceil(target*100/req.SuccessRate, ConstraintSuccessRate)
+	if err != nil {
+		return Plan{}, err
+	}
+
+	model := req.CostModel
+	if len(model.Tiers) == 0 {
+		model = DefaultCostModel
+	}
+	breakdown, err := model.Calculate(p.CarsBuilt, p.Lines)
+	if err != nil {
+		return Plan{}, err
+	}
+	p.Breakdown = breakdown
+	p.Cost = breakdown.Total
+	if req.Budget > 0 && p.Cost > req.Budget {
+		return Plan{}, &InfeasibleError{Constraint: ConstraintBudget,
+			Required: float64(p.Cost) / 100, Limit: float64(req.Budget) / 100}
+	}
+	return p, nil
+}
+
+// ceil rounds x up, ignoring floating-point noise just above a whole number.
+// A value too large for an int is an *InfeasibleError for the constraint.
+func ceil(x float64, constraint Constraint) (int, error) {
+	c := math.Ceil(x - 1e-9)
+	// float64(math.MaxInt) rounds up to 2⁶³, which no int reaches.
+	if !(c < float64(math.MaxInt)) {
+		return 0, &InfeasibleError{Constraint: constraint, Required: x, Limit: math.MaxInt}
+	}
+	return int(c), nil
+}
+
+func finite(x float64) bool {
+	return !math.IsNaN(x) && !math.IsInf(x, 0)
+}
+
+// planCSVHeader lists the columns written by WritePlansCSV.
+var planCSVHeader = []string{"production_rate", "lines", "hours", "cars_built", "working_cars", "cost"}
+
+// WritePlansCSV writes one row per plan, after a header row. Costs are
+// written in major units with two decimals.
+func WritePlansCSV(w io.Writer, plans ...Plan) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(planCSVHeader); err != nil {
+		return err
+	}
+	for _, p := range plans {
+		err := cw.Write([]string{
+			strconv.Itoa(p.ProductionRate),
+			strconv.Itoa(p.Lines),
+			strconv.FormatFloat(p.Hours, 'f', -1, 64),
+			strconv.Itoa(p.CarsBuilt),
+			strconv.FormatFloat(p.WorkingCars, 'f', -1, 64),
+			p.Cost.String(),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
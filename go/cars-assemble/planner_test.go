@@ -0,0 +1,167 @@
+package cars
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestSolvePlan(t *testing.T) {
+	tests := []struct {
+		name string
+		req  PlanRequest
+		want Plan
+	}{
+		{
+			name: "solve for hours",
+			req:  PlanRequest{Target: 5000, SuccessRate: 80, ProductionRate: 250, Lines: 2},
+			want: Plan{ProductionRate: 250, Lines: 2, Hours: 12.5, CarsBuilt: 6250, WorkingCars: 5000, Cost: 5937500000},
+		},
+		{
+			name: "solve for production rate",
+			req:  PlanRequest{Target: 5000, SuccessRate: 80, Lines: 2, Hours: 12},
+			want: Plan{ProductionRate: 261, Lines: 2, Hours: 12, CarsBuilt: 6250, WorkingCars: 5011.2, Cost: 5937500000},
+		},
+		{
+			name: "solve for lines",
+			req:  PlanRequest{Target: 5000, SuccessRate: 80, ProductionRate: 250, Hours: 10},
+			want: Plan{ProductionRate: 250, Lines: 3, Hours: 10, CarsBuilt: 6250, WorkingCars: 6000, Cost: 5937500000},
+		},
+		{
+			name: "custom cost model with line overhead",
+			req: PlanRequest{Target: 10, SuccessRate: 100, ProductionRate: 5, Hours: 1,
+				CostModel: CostModel{Tiers: []Tier{{Size: 1, Price: 100}}, LineOverhead: 1000}},
+			want: Plan{ProductionRate: 5, Lines: 2, Hours: 1, CarsBuilt: 10, WorkingCars: 10, Cost: 3000},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SolvePlan(tt.req)
+			if err != nil {
+				t.Fatalf("SolvePlan() error = %v", err)
+			}
+			if got.ProductionRate != tt.want.ProductionRate || got.Lines != tt.want.Lines ||
+				!floatingPointEquals(got.Hours, tt.want.Hours) || got.CarsBuilt != tt.want.CarsBuilt ||
+				!floatingPointEquals(got.WorkingCars, tt.want.WorkingCars) || got.Cost != tt.want.Cost {
+				t.Errorf("SolvePlan() = %+v, want %+v", got, tt.want)
+			}
+			if got.WorkingCars < float64(tt.req.Target) {
+				t.Errorf("SolvePlan() produces %v working cars, target is %d", got.WorkingCars, tt.req.Target)
+			}
+		})
+	}
+}
+
+func TestSolvePlanInfeasible(t *testing.T) {
+	tests := []struct {
+		name string
+		req  PlanRequest
+		want Constraint
+	}{
+		{
+			name: "not enough hours",
+			req:  PlanRequest{Target: 5000, SuccessRate: 80, ProductionRate: 250, Lines: 2, MaxHours: 10},
+			want: ConstraintHours,
+		},
+		{
+			name: "line too slow",
+			req:  PlanRequest{Target: 5000, SuccessRate: 80, Lines: 2, Hours: 12, MaxProductionRate: 200},
+			want: ConstraintProductionRate,
+		},
+		{
+			name: "not enough lines",
+			req:  PlanRequest{Target: 5000, SuccessRate: 80, ProductionRate: 250, Hours: 10, MaxLines: 2},
+			want: ConstraintLines,
+		},
+		{
+			name: "over budget",
+			req:  PlanRequest{Target: 5000, SuccessRate: 80, ProductionRate: 250, Lines: 2, Budget: 500000000},
+			want: ConstraintBudget,
+		},
+		{
+			name: "nothing works",
+			req:  PlanRequest{Target: 1, SuccessRate: 0, ProductionRate: 250, Lines: 2},
+			want: ConstraintSuccessRate,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := SolvePlan(tt.req)
+			var infeasible *InfeasibleError
+			if !errors.As(err, &infeasible) || !errors.Is(err, ErrInfeasible) {
+				t.Fatalf("SolvePlan() error = %v, want an *InfeasibleError", err)
+			}
+			if infeasible.Constraint != tt.want {
+				t.Errorf("SolvePlan() binding constraint = %q, want %q", infeasible.Constraint, tt.want)
+			}
+		})
+	}
+
+	// A rate too large for an int is infeasible, not a wrapped negative.
+	_, err := SolvePlan(PlanRequest{Target: 5000, SuccessRate: 80, Lines: 2, Hours: 1e-300, MaxProductionRate: 1000})
+	var infeasible *InfeasibleError
+	if !errors.As(err, &infeasible) || infeasible.Constraint != ConstraintProductionRate {
+		t.Errorf("SolvePlan() with 1e-300 hours error = %v, want a production rate *InfeasibleError", err)
+	}
+
+	// A 0% success rate reports the target against the nothing it yields.
+	_, err = SolvePlan(PlanRequest{Target: 12, ProductionRate: 250, Lines: 2})
+	if want := "plan is infeasible: success rate needs 12, limit is 0"; err == nil || err.Error() != want {
+		t.Errorf("SolvePlan() error = %v, want %q", err, want)
+	}
+}
+
+func TestSolvePlanInvalidInput(t *testing.T) {
+	for _, req := range []PlanRequest{
+		{Target: 10, SuccessRate: 80, ProductionRate: 10, Lines: 1, Hours: 1},
+		{Target: 10, SuccessRate: 80, ProductionRate: 10},
+		{Target: 10, SuccessRate: 180, ProductionRate: 10, Lines: 1},
+		{Target: -1, SuccessRate: 80, ProductionRate: 10, Lines: 1},
+		{Target: 10, SuccessRate: 80, ProductionRate: 10, Lines: 1, MaxHours: -1},
+		{Target: 10, SuccessRate: 80, Lines: 1, Hours: 1, MaxProductionRate: -1},
+		{Target: 10, SuccessRate: 80, ProductionRate: 10, Hours: 1, MaxLines: -1},
+		{Target: 10, SuccessRate: 80, ProductionRate: 10, Lines: 1, Budget: -1},
+		{Target: 10, SuccessRate: 80, Lines: 2, Hours: math.NaN()},
+		{Target: 10, SuccessRate: 80, Lines: 2, Hours: math.Inf(1)},
+		{Target: 10, SuccessRate: math.NaN(), Lines: 2, Hours: 1},
+		// Given values over their limits.
+		{Target: 10, SuccessRate: 80, ProductionRate: 300, Lines: 50, MaxLines: 3},
+		{Target: 10, SuccessRate: 80, ProductionRate: 300, Hours: 9, MaxHours: 8},
+		{Target: 10, SuccessRate: 80, ProductionRate: 300, Lines: 2, MaxProductionRate: 200},
+	} {
+		if _, err := SolvePlan(req); !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("SolvePlan(%+v) error = %v, want %v", req, err, ErrInvalidInput)
+		}
+	}
+}
+
+func TestPlanExport(t *testing.T) {
+	plan, err := SolvePlan(PlanRequest{Target: 5000, SuccessRate: 80, ProductionRate: 250, Lines: 2})
+	if err != nil {
+		t.Fatalf("SolvePlan() error = %v", err)
+	}
+
+	data, err := json.Marshal(plan)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	var decoded Plan
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded.Cost != plan.Cost || decoded.Hours != plan.Hours {
+		t.Errorf("JSON round trip = %+v, %v, want %+v", decoded, err, plan)
+	}
+	if !strings.Contains(string(data), `"production_rate":250`) {
+		t.Errorf("json.Marshal() = %s, want snake_case keys", data)
+	}
+
+	var buf bytes.Buffer
+	if err := WritePlansCSV(&buf, plan); err != nil {
+		t.Fatalf("WritePlansCSV() error = %v", err)
+	}
+	want := "production_rate,lines,hours,cars_built,working_cars,cost\n250,2,12.5,6250,5000,59375000.00\n"
+	if got := buf.String(); got != want {
+		t.Errorf("WritePlansCSV() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,503 @@
+package cars
+
+import (
+	"cmp"
+	"container/heap"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"time"
+)
+
+// Station is one step of an assembly line.
+type Station struct {
+	Name string
+	// ProductionRate is how many cars the station handles per hour while running.
+	ProductionRate int
+	// SuccessRate is the percentage of handled cars that pass; the rest are scrapped.
+	SuccessRate float64
+	// MeanTimeToFailure is the mean of the exponentially distributed time
+	// between breakdowns. Zero means the station never breaks down.
+	MeanTimeToFailure time.Duration
+	// MeanTimeToRepair is the mean of the exponentially distributed repair time.
+	MeanTimeToRepair time.Duration
+	// BufferCapacity is how many cars can wait in front of the station.
+	// The first station always has raw material available.
+	BufferCapacity int
+}
+
+// Shift is a daily working period, as offsets from midnight.
+type Shift struct {
+	Start, End time.Duration
+}
+
+// Line is a sequence of stations that cars pass through in order.
+// A line without shifts runs around the clock.
+type Line struct {
+	Stations []Station
+	Shifts   []Shift
+}
+
+// SimulationConfig controls a simulation run.
+type SimulationConfig struct {
+	Duration time.Duration
+	// SampleInterval is how often work in progress is recorded. Zero disables sampling.
+	SampleInterval time.Duration
+	Seed           uint64
+}
+
+// StationReport describes how a station spent the simulated time.
+type StationReport struct {
+	Name      string
+	Processed int
+	Scrapped  int
+	Working   time.Duration
+	Starved   time.Duration
+	Blocked   time.Duration
+	Down      time.Duration
+	OffShift  time.Duration
+	// Utilisation is the share of scheduled time spent working on cars.
+	Utilisation float64
+}
+
+// WIPSample is the number of cars on the line at a point in time.
+type WIPSample struct {
+	At   time.Duration
+	Cars int
+}
+
+// SimulationReport is the outcome of a simulation run.
+type SimulationReport struct {
+	Duration time.Duration
+	// Produced is the number of working cars that left the last station.
+	Produced int
+	// Throughput is Produced per hour.
+	Throughput float64
+	Stations   []StationReport
+	// Bottleneck is the station that was active, i.e. working or under
+	// repair, for the largest share of its scheduled time.
+	Bottleneck string
+	WIP        []WIPSample
+}
+
+// Validate checks that the line can be simulated.
+func (l Line) Validate() error {
+	if len(l.Stations) == 0 {
+		return fmt.Errorf("%w: line has no stations", ErrInvalidInput)
+	}
+	for _, s := range l.Stations {
+		if s.ProductionRate <= 0 || s.SuccessRate < 0 || s.SuccessRate > 100 ||
+			s.MeanTimeToFailure < 0 || s.MeanTimeToRepair < 0 || s.BufferCapacity < 0 {
+			return fmt.Errorf("%w: station %q", ErrInvalidInput, s.Name)
+		}
+	}
+	for _, shift := range l.Shifts {
+		if shift.Start < 0 || shift.Start >= shift.End || shift.End > 24*time.Hour {
+			return fmt.Errorf("%w: shift %v-%v", ErrInvalidInput, shift.Start, shift.End)
+		}
+	}
+	return nil
+}
+
+// ScheduledFraction returns the share of each day covered by the shifts.
+func (l Line) ScheduledFraction() float64 {
+	if len(l.Shifts) == 0 {
+		return 1
+	}
+	shifts := slices.Clone(l.Shifts)
+	slices.SortFunc(shifts, func(a, b Shift) int { return cmp.Compare(a.Start, b.Start) })
+	var covered, end time.Duration
+	for _, shift := range shifts {
+		// Count only the part of each shift that earlier shifts did not cover.
+		if shift.End > end {
+			covered += shift.End - max(shift.Start, end)
+			end = shift.End
+		}
+	}
+	return float64(covered) / float64(24*time.Hour)
+}
+
+func (l Line) onShift(t time.Duration) bool {
+	if len(l.Shifts) == 0 {
+		return true
+	}
+	t %= 24 * time.Hour
+	for _, shift := range l.Shifts {
+		if t >= shift.Start && t < shift.End {
+			return true
+		}
+	}
+	return false
+}
+
+// ExpectedWorkingCarsPerHour is the steady-state output of the line, averaged
+// over the whole day. Each station can deliver CalculateWorkingCarsPerHour
+// cars while available; the cars it passes on are thinned by the success
+// rates of the stations after it, and the slowest station sets the pace.
+func (l Line) ExpectedWorkingCarsPerHour() float64 {
+	if len(l.Stations) == 0 {
+		return 0
+	}
+	rate := -1.0
+	for i, s := range l.Stations {
+		r := CalculateWorkingCarsPerHour(s.ProductionRate, s.SuccessRate) * availability(s)
+		for _, next := range l.Stations[i+1:] {
+			r *= next.SuccessRate / 100
+		}
+		if rate < 0 || r < rate {
+			rate = r
+		}
+	}
+	return rate * l.ScheduledFraction()
+}
+
+func availability(s Station) float64 {
+	if s.MeanTimeToFailure == 0 {
+		return 1
+	}
+	return float64(s.MeanTimeToFailure) / float64(s.MeanTimeToFailure+s.MeanTimeToRepair)
+}
+
+// Simulate runs a discrete-event simulation of the line. Handling a car
+// takes a fixed time derived from the station's production rate; breakdowns
+// and repairs are random. A station that breaks down or goes off shift in
+// the middle of a car picks it up where it left off.
+func Simulate(line Line, cfg SimulationConfig) (SimulationReport, error) {
+	if err := line.Validate(); err != nil {
+		return SimulationReport{}, err
+	}
+	if cfg.Duration <= 0 || cfg.SampleInterval < 0 {
+		return SimulationReport{}, fmt.Errorf("%w: duration %v, sample interval %v", ErrInvalidInput, cfg.Duration, cfg.SampleInterval)
+	}
+
+	s := &simulation{
+		line:    line,
+		rng:     rand.New(rand.NewPCG(cfg.Seed, cfg.Seed)),
+		onShift: line.onShift(0),
+	}
+	s.stations = make([]stationSim, len(line.Stations))
+	for i, st := range line.Stations {
+		s.stations[i] = stationSim{Station: st, work: time.Hour / time.Duration(st.ProductionRate)}
+		s.scheduleFailure(i)
+	}
+	for day := time.Duration(0); day < cfg.Duration; day += 24 * time.Hour {
+		for _, shift := range line.Shifts {
+			s.push(event{at: day + shift.Start, kind: shiftChange})
+			s.push(event{at: day + shift.End, kind: shiftChange})
+		}
+	}
+	if cfg.SampleInterval > 0 {
+		s.push(event{at: 0, kind: sample})
+	}
+	for i := range s.stations {
+		s.tryStart(i)
+	}
+	s.updateStates()
+
+	var report SimulationReport
+	for s.events.Len() > 0 {
+		e := heap.Pop(&s.events).(event)
+		if e.at > cfg.Duration {
+			break
+		}
+		s.now = e.at
+		switch e.kind {
+		case finish:
+			if e.version == s.stations[e.station].version {
+				s.finish(e.station)
+			}
+		case failure:
+			s.fail(e.station)
+		case repair:
+			s.repair(e.station)
+		case shiftChange:
+			s.changeShift()
+		case sample:
+			report.WIP = append(report.WIP, WIPSample{At: s.now, Cars: s.wip()})
+			if next := s.now + cfg.SampleInterval; next < cfg.Duration {
+				s.push(event{at: next, kind: sample})
+			}
+		}
+		s.updateStates()
+	}
+	s.now = cfg.Duration
+	s.updateStates()
+
+	report.Duration = cfg.Duration
+	report.Produced = s.produced
+	report.Throughput = float64(s.produced) / cfg.Duration.Hours()
+	bestActive := -1.0
+	for _, st := range s.stations {
+		r := StationReport{
+			Name:      st.Name,
+			Processed: st.processed,
+			Scrapped:  st.scrapped,
+			Working:   st.timeIn[working],
+			Starved:   st.timeIn[starved],
+			Blocked:   st.timeIn[blocked],
+			Down:      st.timeIn[down],
+			OffShift:  st.timeIn[offShift],
+		}
+		active := 0.0
+		if scheduled := cfg.Duration - r.OffShift; scheduled > 0 {
+			r.Utilisation = float64(r.Working) / float64(scheduled)
+			active = float64(r.Working+r.Down) / float64(scheduled)
+		}
+		if active > bestActive {
+			bestActive = active
+			report.Bottleneck = r.Name
+		}
+		report.Stations = append(report.Stations, r)
+	}
+	return report, nil
+}
+
+type stationState int
+
+const (
+	starved stationState = iota
+	working
+	blocked
+	down
+	offShift
+	stationStates
+)
+
+type stationSim struct {
+	Station
+	work time.Duration
+
+	queue     int           // cars waiting in the input buffer
+	holding   bool          // a car is at the station
+	done      bool          // the held car is finished and waiting to move on
+	running   bool          // a finish event is pending for the held car
+	remaining time.Duration // work left on the held car while paused
+	finishAt  time.Duration
+	failed    bool
+	version   int // invalidates finish events of paused work
+
+	state     stationState
+	since     time.Duration
+	timeIn    [stationStates]time.Duration
+	processed int
+	scrapped  int
+}
+
+type eventKind int
+
+const (
+	finish eventKind = iota
+	failure
+	repair
+	shiftChange
+	sample
+)
+
+type event struct {
+	at      time.Duration
+	seq     int
+	kind    eventKind
+	station int
+	version int
+}
+
+// eventQueue orders events by time, then by the order they were scheduled.
+type eventQueue []event
+
+func (q eventQueue) Len() int { return len(q) }
+func (q eventQueue) Less(i, j int) bool {
+	if q[i].at != q[j].at {
+		return q[i].at < q[j].at
+	}
+	return q[i].seq < q[j].seq
+}
+func (q eventQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+func (q *eventQueue) Push(x any)   { *q = append(*q, x.(event)) }
+func (q *eventQueue) Pop() any {
+	old := *q
+	e := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return e
+}
+
+type simulation struct {
+	line     Line
+	rng      *rand.Rand
+	now      time.Duration
+	events   eventQueue
+	seq      int
+	stations []stationSim
+	onShift  bool
+	produced int
+}
+
+func (s *simulation) push(e event) {
+	e.seq = s.seq
+	s.seq++
+	heap.Push(&s.events, e)
+}
+
+func (s *simulation) exponential(mean time.Duration) time.Duration {
+	return time.Duration(s.rng.ExpFloat64() * float64(mean))
+}
+
+func (s *simulation) canRun(i int) bool {
+	return s.onShift && !s.stations[i].failed
+}
+
+// tryStart lets station i take its next car, pulling from upstream if needed.
+func (s *simulation) tryStart(i int) {
+	st := &s.stations[i]
+	if st.holding || !s.canRun(i) {
+		return
+	}
+	if i > 0 {
+		if st.queue == 0 {
+			// The buffer may be too small to hold the upstream car until now.
+			s.tryPass(i - 1)
+			return
+		}
+		st.queue--
+	}
+	st.holding = true
+	st.remaining = st.work
+	s.run(i)
+	if i > 0 {
+		s.tryPass(i - 1)
+	}
+}
+
+func (s *simulation) run(i int) {
+	st := &s.stations[i]
+	st.running = true
+	st.finishAt = s.now + st.remaining
+	st.version++
+	s.push(event{at: st.finishAt, kind: finish, station: i, version: st.version})
+}
+
+func (s *simulation) pause(i int) {
+	st := &s.stations[i]
+	if st.running {
+		st.running = false
+		st.remaining = st.finishAt - s.now
+		st.version++
+	}
+}
+
+func (s *simulation) resume(i int) {
+	st := &s.stations[i]
+	switch {
+	case st.running || !s.canRun(i):
+	case st.holding && !st.done:
+		s.run(i)
+	case st.holding:
+		s.tryPass(i)
+	default:
+		s.tryStart(i)
+	}
+}
+
+func (s *simulation) finish(i int) {
+	st := &s.stations[i]
+	st.running = false
+	st.remaining = 0
+	st.processed++
+	if s.rng.Float64()*100 < st.SuccessRate {
+		st.done = true
+		s.tryPass(i)
+		return
+	}
+	st.scrapped++
+	st.holding = false
+	s.tryStart(i)
+}
+
+// tryPass moves the finished car at station i on to the next station.
+func (s *simulation) tryPass(i int) {
+	st := &s.stations[i]
+	if !st.done {
+		return
+	}
+	if i == len(s.stations)-1 {
+		s.produced++
+	} else {
+		next := &s.stations[i+1]
+		if next.queue >= next.BufferCapacity && (next.holding || !s.canRun(i+1)) {
+			return
+		}
+		next.queue++
+	}
+	st.done = false
+	st.holding = false
+	if i < len(s.stations)-1 {
+		s.tryStart(i + 1)
+	}
+	s.tryStart(i)
+}
+
+func (s *simulation) scheduleFailure(i int) {
+	if mttf := s.stations[i].MeanTimeToFailure; mttf > 0 {
+		s.push(event{at: s.now + s.exponential(mttf), kind: failure, station: i})
+	}
+}
+
+func (s *simulation) fail(i int) {
+	s.stations[i].failed = true
+	s.pause(i)
+	s.push(event{at: s.now + s.exponential(s.stations[i].MeanTimeToRepair), kind: repair, station: i})
+}
+
+func (s *simulation) repair(i int) {
+	s.stations[i].failed = false
+	s.scheduleFailure(i)
+	s.resume(i)
+}
+
+func (s *simulation) changeShift() {
+	onShift := s.line.onShift(s.now)
+	if onShift == s.onShift {
+		// One shift ended as the next one started.
+		return
+	}
+	s.onShift = onShift
+	for i := range s.stations {
+		if s.onShift {
+			s.resume(i)
+		} else {
+			s.pause(i)
+		}
+	}
+}
+
+func (s *simulation) wip() int {
+	cars := 0
+	for _, st := range s.stations {
+		cars += st.queue
+		if st.holding {
+			cars++
+		}
+	}
+	return cars
+}
+
+// updateStates books the time since the last event to each station's
+// previous state and works out its current one.
+func (s *simulation) updateStates() {
+	for i := range s.stations {
+		st := &s.stations[i]
+		st.timeIn[st.state] += s.now - st.since
+		st.since = s.now
+		switch {
+		case st.failed:
+			st.state = down
+		case !s.onShift:
+			st.state = offShift
+		case st.running:
+			st.state = working
+		case st.done:
+			st.state = blocked
+		default:
+			st.state = starved
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package cars
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSimulateSteadyState(t *testing.T) {
+	tests := []struct {
+		name      string
+		line      Line
+		duration  time.Duration
+		tolerance float64
+	}{
+		{
+			name:      "single reliable station",
+			line:      Line{Stations: []Station{{Name: "assembly", ProductionRate: 60, SuccessRate: 90}}},
+			duration:  500 * time.Hour,
+			tolerance: 0.02,
+		},
+		{
+			name: "slow second station is the bottleneck",
+			line: Line{Stations: []Station{
+				{Name: "body", ProductionRate: 60, SuccessRate: 90},
+				{Name: "paint", ProductionRate: 40, SuccessRate: 100, BufferCapacity: 5},
+			}},
+			duration:  200 * time.Hour,
+			tolerance: 0.01,
+		},
+		{
+			name: "station with breakdowns",
+			line: Line{Stations: []Station{{
+				Name:              "assembly",
+				ProductionRate:    60,
+				SuccessRate:       100,
+				MeanTimeToFailure: time.Hour,
+				MeanTimeToRepair:  15 * time.Minute,
+			}}},
+			duration:  5000 * time.Hour,
+			tolerance: 0.03,
+		},
+		{
+			name: "one daily shift",
+			line: Line{
+				Stations: []Station{{Name: "assembly", ProductionRate: 30, SuccessRate: 100}},
+				Shifts:   []Shift{{Start: 6 * time.Hour, End: 14 * time.Hour}},
+			},
+			duration:  10 * 24 * time.Hour,
+			tolerance: 0.001,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Simulate(tt.line, SimulationConfig{Duration: tt.duration, Seed: 7})
+			if err != nil {
+				t.Fatalf("Simulate() error = %v", err)
+			}
+			want := tt.line.ExpectedWorkingCarsPerHour()
+			if math.Abs(got.Throughput-want)/want > tt.tolerance {
+				t.Errorf("Simulate() throughput = %.2f, steady state %.2f", got.Throughput, want)
+			}
+		})
+	}
+}
+
+func TestSimulateReport(t *testing.T) {
+	line := Line{
+		Stations: []Station{
+			{Name: "body", ProductionRate: 60, SuccessRate: 100},
+			{Name: "paint", ProductionRate: 20, SuccessRate: 100, BufferCapacity: 3},
+			{Name: "qa", ProductionRate: 60, SuccessRate: 50},
+		},
+		Shifts: []Shift{{Start: 0, End: 12 * time.Hour}},
+	}
+	got, err := Simulate(line, SimulationConfig{Duration: 24 * time.Hour, SampleInterval: time.Hour, Seed: 1})
+	if err != nil {
+		t.Fatalf("Simulate() error = %v", err)
+	}
+	if got.Bottleneck != "paint" {
+		t.Errorf("Simulate() bottleneck = %q, want %q", got.Bottleneck, "paint")
+	}
+	if len(got.WIP) != 24 {
+		t.Errorf("Simulate() recorded %d WIP samples, want 24", len(got.WIP))
+	}
+	for _, s := range got.WIP {
+		if s.Cars < 0 || s.Cars > 3+len(line.Stations) {
+			t.Errorf("WIP at %v = %d cars, more than the line holds", s.At, s.Cars)
+		}
+	}
+	for _, st := range got.Stations {
+		total := st.Working + st.Starved + st.Blocked + st.Down + st.OffShift
+		if total != 24*time.Hour {
+			t.Errorf("station %q states add up to %v, want 24h", st.Name, total)
+		}
+		if st.OffShift != 12*time.Hour {
+			t.Errorf("station %q off shift for %v, want 12h", st.Name, st.OffShift)
+		}
+	}
+	body := got.Stations[0]
+	if body.Blocked == 0 {
+		t.Errorf("body was never blocked behind the slow paint station")
+	}
+	qa := got.Stations[2]
+	if qa.Processed != got.Produced+qa.Scrapped {
+		t.Errorf("qa processed %d cars, produced %d and scrapped %d", qa.Processed, got.Produced, qa.Scrapped)
+	}
+}
+
+func TestSimulateInvalidInput(t *testing.T) {
+	valid := Line{Stations: []Station{{Name: "assembly", ProductionRate: 1, SuccessRate: 100}}}
+	tests := []struct {
+		name string
+		line Line
+		cfg  SimulationConfig
+	}{
+		{name: "no stations", line: Line{}, cfg: SimulationConfig{Duration: time.Hour}},
+		{name: "zero rate", line: Line{Stations: []Station{{SuccessRate: 100}}}, cfg: SimulationConfig{Duration: time.Hour}},
+		{name: "success over 100%", line: Line{Stations: []Station{{ProductionRate: 1, SuccessRate: 101}}}, cfg: SimulationConfig{Duration: time.Hour}},
+		{name: "shift past midnight", line: Line{Stations: valid.Stations, Shifts: []Shift{{Start: 22 * time.Hour, End: 26 * time.Hour}}}, cfg: SimulationConfig{Duration: time.Hour}},
+		{name: "zero duration", line: valid, cfg: SimulationConfig{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Simulate(tt.line, tt.cfg); !errors.Is(err, ErrInvalidInput) {
+				t.Errorf("Simulate() error = %v, want %v", err, ErrInvalidInput)
+			}
+		})
+	}
+}
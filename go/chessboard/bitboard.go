@@ -0,0 +1,269 @@
+package chessboard
+
+import (
+	"fmt"
+	"iter"
+	"math/bits"
+)
+
+// Bitboard is a set of squares, one bit per square with A1 as bit 0.
+type Bitboard uint64
+
+// SquareBit returns the bitboard holding only the given square.
+func SquareBit(s Square) Bitboard {
+	return 1 << uint(s)
+}
+
+// Has reports whether the square is in the set.
+func (b Bitboard) Has(s Square) bool {
+	return b&SquareBit(s) != 0
+}
+
+// Count returns the number of squares in the set.
+func (b Bitboard) Count() int {
+	return bits.OnesCount64(uint64(b))
+}
+
+// First returns the lowest square in the set, or NoSquare if it is empty.
+func (b Bitboard) First() Square {
+	if b == 0 {
+		return NoSquare
+	}
+	return Square(bits.TrailingZeros64(uint64(b)))
+}
+
+// Squares iterates over the squares in the set from A1 to H8.
+func (b Bitboard) Squares() iter.Seq[Square] {
+	return func(yield func(Square) bool) {
+		for b != 0 {
+			s := b.First()
+			b &= b - 1
+			if !yield(s) {
+				return
+			}
+		}
+	}
+}
+
+// FileMasks holds the squares of each file, A to H.
+var FileMasks [8]Bitboard
+
+// RankMasks holds the squares of each rank, 1 to 8.
+var RankMasks [8]Bitboard
+
+const (
+	notFileA  Bitboard = 0xfefefefefefefefe
+	notFileH  Bitboard = 0x7f7f7f7f7f7f7f7f
+	notFileAB Bitboard = 0xfcfcfcfcfcfcfcfc
+	notFileGH Bitboard = 0x3f3f3f3f3f3f3f3f
+)
+
+// CountInFile returns how many squares of the set are in the given file,
+// named "A" to "H".
+func (b Bitboard) CountInFile(file string) int {
+	f, ok := fileIndex(file)
+	if !ok {
+		return 0
+	}
+	return (b & FileMasks[f]).Count()
+}
+
+// CountInRank returns how many squares of the set are in the given rank.
+func (b Bitboard) CountInRank(rank int) int {
+	if rank < 1 || rank > 8 {
+		return 0
+	}
+	return (b & RankMasks[rank-1]).Count()
+}
+
+// CountOccupied returns how many squares are in the set.
+func (b Bitboard) CountOccupied() int {
+	return b.Count()
+}
+
+// BitboardFromChessboard converts an occupancy map into a bitboard.
+// Files and ranks outside the board are ignored.
+func BitboardFromChessboard(cb Chessboard) Bitboard {
+	var b Bitboard
+	for name, squares := range cb {
+		file, ok := fileIndex(name)
+		if !ok {
+			continue
+		}
+		for rank, occupied := range squares {
+			if occupied && rank < 8 {
+				b |= SquareBit(NewSquare(file, rank))
+			}
+		}
+	}
+	return b
+}
+
+// Chessboard converts the bitboard into an occupancy map with all eight files.
+func (b Bitboard) Chessboard() Chessboard {
+	cb := make(Chessboard, 8)
+	for file := 0; file < 8; file++ {
+		f := make(File, 8)
+		for rank := range f {
+			f[rank] = b.Has(NewSquare(file, rank))
+		}
+		cb[string(rune('A'+file))] = f
+	}
+	return cb
+}
+
+func north(b Bitboard) Bitboard { return b << 8 }
+func south(b Bitboard) Bitboard { return b >> 8 }
+func east(b Bitboard) Bitboard  { return (b << 1) & notFileA }
+func west(b Bitboard) Bitboard  { return (b >> 1) & notFileH }
+
+var (
+	knightAttacks [64]Bitboard
+	kingAttacks   [64]Bitboard
+	pawnAttacks   [2][64]Bitboard
+)
+
+// KnightAttacks returns the squares a knight on s attacks.
+func KnightAttacks(s Square) Bitboard {
+	return knightAttacks[s]
+}
+
+// KingAttacks returns the squares a king on s attacks.
+func KingAttacks(s Square) Bitboard {
+	return kingAttacks[s]
+}
+
+// PawnAttacks returns the squares a pawn of the given color on s attacks.
+func PawnAttacks(c Color, s Square) Bitboard {
+	return pawnAttacks[c][s]
+}
+
+// BishopAttacks returns the squares a bishop on s attacks, given the
+// occupied squares that block it.
+func BishopAttacks(s Square, occupied Bitboard) Bitboard {
+	return bishopMagics[s].attacks(occupied)
+}
+
+// RookAttacks returns the squares a rook on s attacks, given the occupied
+// squares that block it.
+func RookAttacks(s Square, occupied Bitboard) Bitboard {
+	return rookMagics[s].attacks(occupied)
+}
+
+// QueenAttacks returns the squares a queen on s attacks, given the
+// occupied squares that block it.
+func QueenAttacks(s Square, occupied Bitboard) Bitboard {
+	return BishopAttacks(s, occupied) | RookAttacks(s, occupied)
+}
+
+// magic finds the attack set of a sliding piece by multiplying the
+// relevant blockers with a magic number whose top bits index a table.
+type magic struct {
+	mask   Bitboard
+	number uint64
+	shift  uint
+	table  []Bitboard
+}
+
+func (m *magic) attacks(occupied Bitboard) Bitboard {
+	return m.table[(uint64(occupied&m.mask)*m.number)>>m.shift]
+}
+
+var bishopMagics, rookMagics [64]magic
+
+var (
+	bishopDirections = [][2]int{{1, 1}, {1, -1}, {-1, 1}, {-1, -1}}
+	rookDirections   = [][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}}
+)
+
+func init() {
+	for i := 0; i < 8; i++ {
+		FileMasks[i] = 0x0101010101010101 << i
+		RankMasks[i] = 0xff << (8 * i)
+	}
+	for s := Square(0); s < 64; s++ {
+		b := SquareBit(s)
+		knightAttacks[s] = (b<<17|b>>15)&notFileA | (b<<15|b>>17)&notFileH |
+			(b<<10|b>>6)&notFileAB | (b<<6|b>>10)&notFileGH
+		kingAttacks[s] = north(b) | south(b) | east(b|north(b)|south(b)) | west(b|north(b)|south(b))
+		pawnAttacks[White][s] = east(north(b)) | west(north(b))
+		pawnAttacks[Black][s] = east(south(b)) | west(south(b))
+	}
+
+	// The magic numbers are checked in, see magics.go, so only the tables
+	// are built here.
+	for s := Square(0); s < 64; s++ {
+		var ok [2]bool
+		bishopMagics[s], ok[0] = newMagic(s, bishopDirections, bishopMagicNumbers[s])
+		rookMagics[s], ok[1] = newMagic(s, rookDirections, rookMagicNumbers[s])
+		if !ok[0] || !ok[1] {
+			panic(fmt.Sprintf("chessboard: bad magic number for %v", s))
+		}
+	}
+}
+
+// slidingAttacks walks each direction from s until it leaves the board or
+// hits an occupied square, which is included.
+func slidingAttacks(s Square, occupied Bitboard, directions [][2]int) Bitboard {
+	var attacks Bitboard
+	for _, d := range directions {
+		f, r := s.File()+d[0], s.Rank()+d[1]
+		for f >= 0 && f < 8 && r >= 0 && r < 8 {
+			sq := NewSquare(f, r)
+			attacks |= SquareBit(sq)
+			if occupied.Has(sq) {
+				break
+			}
+			f, r = f+d[0], r+d[1]
+		}
+	}
+	return attacks
+}
+
+// relevantMask is the set of squares whose occupancy can change the
+// attacks from s: the rays without the last square before the edge.
+func relevantMask(s Square, directions [][2]int) Bitboard {
+	var mask Bitboard
+	for _, d := range directions {
+		f, r := s.File()+d[0], s.Rank()+d[1]
+		for f+d[0] >= 0 && f+d[0] < 8 && r+d[1] >= 0 && r+d[1] < 8 {
+			mask |= SquareBit(NewSquare(f, r))
+			f, r = f+d[0], r+d[1]
+		}
+	}
+	return mask
+}
+
+// newMagic builds the attack table of a sliding piece on s for a magic
+// number. It reports false if the number maps two blocker sets with
+// different attacks to the same entry.
+func newMagic(s Square, directions [][2]int, number uint64) (magic, bool) {
+	mask := relevantMask(s, directions)
+	n := mask.Count()
+	m := magic{mask: mask, number: number, shift: uint(64 - n), table: make([]Bitboard, 1<<n)}
+	used := make([]bool, 1<<n)
+	// Enumerate every subset of the mask with the carry-rippler trick.
+	for sub := Bitboard(0); ; {
+		idx := (uint64(sub) * number) >> m.shift
+		attacks := slidingAttacks(s, sub, directions)
+		if used[idx] && m.table[idx] != attacks {
+			return magic{}, false
+		}
+		used[idx] = true
+		m.table[idx] = attacks
+		sub = (sub - mask) & mask
+		if sub == 0 {
+			break
+		}
+	}
+	return m, true
+}
+
+type xorshift uint64
+
+func (x *xorshift) next() uint64 {
+	*x ^= *x >> 12
+	*x ^= *x << 25
+	*x ^= *x >> 27
+	return uint64(*x) * 2685821657736338717
+}
@@ -0,0 +1,183 @@
+package chessboard
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"testing"
+)
+
+func TestBitboardCounts(t *testing.T) {
+	cb := newChessboard()
+	b := BitboardFromChessboard(cb)
+	for _, file := range []string{"A", "B", "C", "D", "E", "F", "G", "H", "Z"} {
+		t.Run(fmt.Sprintf("file %s", file), func(t *testing.T) {
+			if got, want := b.CountInFile(file), CountInFile(cb, file); got != want {
+				t.Errorf("CountInFile(%q) = %d, want %d", file, got, want)
+			}
+		})
+	}
+	for rank := -1; rank <= 9; rank++ {
+		t.Run(fmt.Sprintf("rank %d", rank), func(t *testing.T) {
+			if got, want := b.CountInRank(rank), CountInRank(cb, rank); got != want {
+				t.Errorf("CountInRank(%d) = %d, want %d", rank, got, want)
+			}
+		})
+	}
+	if got, want := b.CountOccupied(), CountOccupied(cb); got != want {
+		t.Errorf("CountOccupied() = %d, want %d", got, want)
+	}
+
+	back := b.Chessboard()
+	for file, squares := range cb {
+		for rank, occupied := range squares {
+			if back[file][rank] != occupied {
+				t.Errorf("Chessboard()[%s][%d] = %v, want %v", file, rank, back[file][rank], occupied)
+			}
+		}
+	}
+	if again := BitboardFromChessboard(back); again != b {
+		t.Errorf("BitboardFromChessboard(Chessboard()) = %#x, want %#x", uint64(again), uint64(b))
+	}
+}
+
+func TestBitboardSquares(t *testing.T) {
+	b := SquareBit(0) | SquareBit(27) | SquareBit(63)
+	var got []Square
+	for s := range b.Squares() {
+		got = append(got, s)
+	}
+	if fmt.Sprint(got) != "[a1 d4 h8]" {
+		t.Errorf("Squares() = %v, want [a1 d4 h8]", got)
+	}
+	if Bitboard(0).First() != NoSquare {
+		t.Errorf("First() of an empty set = %v, want NoSquare", Bitboard(0).First())
+	}
+}
+
+// offsetAttacks is a plain reference for the leaper tables.
+func offsetAttacks(s Square, offsets [][2]int) Bitboard {
+	var b Bitboard
+	for _, o := range offsets {
+		f, r := s.File()+o[0], s.Rank()+o[1]
+		if f >= 0 && f < 8 && r >= 0 && r < 8 {
+			b |= SquareBit(NewSquare(f, r))
+		}
+	}
+	return b
+}
+
+func TestLeaperAttacks(t *testing.T) {
+	knight := [][2]int{{1, 2}, {2, 1}, {2, -1}, {1, -2}, {-1, -2}, {-2, -1}, {-2, 1}, {-1, 2}}
+	king := [][2]int{{1, 1}, {1, 0}, {1, -1}, {0, -1}, {-1, -1}, {-1, 0}, {-1, 1}, {0, 1}}
+	for s := Square(0); s < 64; s++ {
+		if got, want := KnightAttacks(s), offsetAttacks(s, knight); got != want {
+			t.Errorf("KnightAttacks(%v) = %#x, want %#x", s, uint64(got), uint64(want))
+		}
+		if got, want := KingAttacks(s), offsetAttacks(s, king); got != want {
+			t.Errorf("KingAttacks(%v) = %#x, want %#x", s, uint64(got), uint64(want))
+		}
+		if got, want := PawnAttacks(White, s), offsetAttacks(s, [][2]int{{-1, 1}, {1, 1}}); got != want {
+			t.Errorf("PawnAttacks(White, %v) = %#x, want %#x", s, uint64(got), uint64(want))
+		}
+		if got, want := PawnAttacks(Black, s), offsetAttacks(s, [][2]int{{-1, -1}, {1, -1}}); got != want {
+			t.Errorf("PawnAttacks(Black, %v) = %#x, want %#x", s, uint64(got), uint64(want))
+		}
+	}
+}
+
+func TestSliderAttacks(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	for s := Square(0); s < 64; s++ {
+		for i := 0; i < 200; i++ {
+			occupied := Bitboard(rng.Uint64() & rng.Uint64())
+			if got, want := BishopAttacks(s, occupied), slidingAttacks(s, occupied, bishopDirections); got != want {
+				t.Fatalf("BishopAttacks(%v, %#x) = %#x, want %#x", s, uint64(occupied), uint64(got), uint64(want))
+			}
+			if got, want := RookAttacks(s, occupied), slidingAttacks(s, occupied, rookDirections); got != want {
+				t.Fatalf("RookAttacks(%v, %#x) = %#x, want %#x", s, uint64(occupied), uint64(got), uint64(want))
+			}
+		}
+	}
+	d4, _ := ParseSquare("d4")
+	if got := QueenAttacks(d4, 0).Count(); got != 27 {
+		t.Errorf("QueenAttacks(d4) on an empty board covers %d squares, want 27", got)
+	}
+}
+
+func TestPositionBitboards(t *testing.T) {
+	p, err := ParseFEN(StartFEN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Occupied() != RankMasks[0]|RankMasks[1]|RankMasks[6]|RankMasks[7] {
+		t.Errorf("Occupied() = %#x", uint64(p.Occupied()))
+	}
+	if got := p.Pieces(Piece{Kind: Pawn, Color: Black}); got != RankMasks[6] {
+		t.Errorf("Pieces(p) = %#x, want rank 7", uint64(got))
+	}
+	e2, _ := ParseSquare("e2")
+	e4, _ := ParseSquare("e4")
+	p.Put(e4, p.Piece(e2))
+	p.Put(e2, NoPiece)
+	white := p.Side(White)
+	if white.Has(e2) || !white.Has(e4) || white.Count() != 16 {
+		t.Errorf("Side(White) after e2-e4 = %#x", uint64(white))
+	}
+	p.Put(e4, Piece{Kind: Knight, Color: Black})
+	if p.Side(White).Has(e4) || !p.Pieces(Piece{Kind: Knight, Color: Black}).Has(e4) ||
+		p.Pieces(Piece{Kind: Pawn, Color: White}).Has(e4) {
+		t.Errorf("replacing a piece left stale bitboards")
+	}
+}
+
+var sink int
+
+func BenchmarkCountInRankMap(b *testing.B) {
+	cb := newChessboard()
+	for i := 0; i < b.N; i++ {
+		for rank := 1; rank <= 8; rank++ {
+			sink += CountInRank(cb, rank)
+		}
+	}
+}
+
+func BenchmarkCountInRankBitboard(b *testing.B) {
+	bb := BitboardFromChessboard(newChessboard())
+	for i := 0; i < b.N; i++ {
+		for rank := 1; rank <= 8; rank++ {
+			sink += bb.CountInRank(rank)
+		}
+	}
+}
+
+func BenchmarkCountInFileMap(b *testing.B) {
+	cb := newChessboard()
+	for i := 0; i < b.N; i++ {
+		for _, file := range []string{"A", "B", "C", "D", "E", "F", "G", "H"} {
+			sink += CountInFile(cb, file)
+		}
+	}
+}
+
+func BenchmarkCountInFileBitboard(b *testing.B) {
+	bb := BitboardFromChessboard(newChessboard())
+	for i := 0; i < b.N; i++ {
+		for _, file := range []string{"A", "B", "C", "D", "E", "F", "G", "H"} {
+			sink += bb.CountInFile(file)
+		}
+	}
+}
+
+func BenchmarkCountOccupiedMap(b *testing.B) {
+	cb := newChessboard()
+	for i := 0; i < b.N; i++ {
+		sink += CountOccupied(cb)
+	}
+}
+
+func BenchmarkCountOccupiedBitboard(b *testing.B) {
+	bb := BitboardFromChessboard(newChessboard())
+	for i := 0; i < b.N; i++ {
+		sink += bb.CountOccupied()
+	}
+}
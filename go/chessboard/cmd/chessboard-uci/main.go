@@ -0,0 +1,17 @@
+// Command chessboard-uci is a chess engine that speaks the UCI protocol on
+// standard input and output.
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"chessboard/uci"
+)
+
+func main() {
+	if err := uci.NewServer().Run(os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "chessboard-uci:", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,81 @@
+package chessboard
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"math/bits"
+	"os"
+	"testing"
+)
+
+// findMagic tries sparse random numbers until one builds a table for s
+// without harmful collisions.
+func findMagic(s Square, directions [][2]int, rng *xorshift) uint64 {
+	mask := relevantMask(s, directions)
+	for {
+		number := rng.next() & rng.next() & rng.next()
+		if bits.OnesCount64((uint64(mask)*number)>>56) < 6 {
+			continue
+		}
+		if _, ok := newMagic(s, directions, number); ok {
+			return number
+		}
+	}
+}
+
+// TestMagicNumbers checks the magic numbers in magics.go, or with -update
+// searches for new ones and rewrites the file.
+func TestMagicNumbers(t *testing.T) {
+	bishops, rooks := bishopMagicNumbers, rookMagicNumbers
+	if *update {
+		// A fixed seed keeps the generated file the same on every run.
+		rng := xorshift(0x9e3779b97f4a7c15)
+		for s := Square(0); s < 64; s++ {
+			bishops[s] = findMagic(s, bishopDirections, &rng)
+			rooks[s] = findMagic(s, rookDirections, &rng)
+		}
+		var buf bytes.Buffer
+		buf.WriteString("// Code generated by go test -run TestMagicNumbers -update; DO NOT EDIT.\n\n")
+		buf.WriteString("package chessboard\n\n// Magic numbers for the sliding piece attack tables, by square.\n")
+		for _, table := range []struct {
+			name    string
+			numbers [64]uint64
+		}{{"bishopMagicNumbers", bishops}, {"rookMagicNumbers", rooks}} {
+			fmt.Fprintf(&buf, "\nvar %s = [64]uint64{\n", table.name)
+			for i, n := range table.numbers {
+				fmt.Fprintf(&buf, "%#016x,", n)
+				if i%4 == 3 {
+					buf.WriteString("\n")
+				} else {
+					buf.WriteString(" ")
+				}
+			}
+			buf.WriteString("}\n")
+		}
+		src, err := format.Source(buf.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile("magics.go", src, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for s := Square(0); s < 64; s++ {
+		for _, m := range []struct {
+			directions [][2]int
+			number     uint64
+		}{{bishopDirections, bishops[s]}, {rookDirections, rooks[s]}} {
+			got, ok := newMagic(s, m.directions, m.number)
+			if !ok {
+				t.Fatalf("magic number %#x does not work on %v", m.number, s)
+			}
+			// Spot-check the table against walking the rays.
+			occupied := Bitboard(0x0000_2410_8100_4200) &^ SquareBit(s)
+			if a, want := got.attacks(occupied), slidingAttacks(s, occupied, m.directions); a != want {
+				t.Errorf("attacks from %v = %#x, want %#x", s, a, want)
+			}
+		}
+	}
+}
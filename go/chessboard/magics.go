@@ -0,0 +1,43 @@
+// Code generated by go test -run TestMagicNumbers -update; DO NOT EDIT.
+
+package chessboard
+
+// Magic numbers for the sliding piece attack tables, by square.
+
+var bishopMagicNumbers = [64]uint64{
+	0x10102002004a1420, 0x3009080104082090, 0x20a2020400200808, 0x0204404080020102,
+	0x0101104000000028, 0x28811008040000e8, 0x1031011032200020, 0x0041040118921000,
+	0x0400041004812400, 0x4100108188008081, 0x0020484604042a09, 0x000002208a002100,
+	0x00000a1210002805, 0x400a410460448100, 0x013060480a086000, 0x2101411400840412,
+	0x1a10100404500409, 0x4010028401026400, 0x2050000800401020, 0x0008202404001420,
+	0x0032880400a00600, 0x0202000022100202, 0x0204082082111040, 0x480c210084010800,
+	0x00c2620410200200, 0x80c2102042901202, 0x9000320050040040, 0x8004080010220040,
+	0x0020044002003004, 0x120401884100a003, 0x2004208014020128, 0x04010302005400a0,
+	0x0950084500600402, 0x81e0900901102200, 0x10040128008412c0, 0x0402004042940100,
+	0x2104204010040100, 0x0420009100802400, 0x0204082220808082, 0x2002004248020218,
+	0x0001042160208400, 0x00440d0148101080, 0x8044a02030000802, 0xc081044206204800,
+	0x0000219020800400, 0x8404010041000201, 0x02210c0102492209, 0x8010012110283100,
+	0x0183880109a00001, 0x1001411090900080, 0x2002120084045420, 0x2126087842020022,
+	0x8040004010410128, 0x08024030c2008020, 0x0121241004812002, 0x0308010822004000,
+	0x0083042805141020, 0x0220804212102288, 0x8000014100880400, 0x1000080000840410,
+	0x0088080031203200, 0x001002200202c202, 0x0000054802540400, 0xa010041108003100,
+}
+
+var rookMagicNumbers = [64]uint64{
+	0x1080004008801020, 0x0840092002c03000, 0x1900200010400900, 0x0880100008000480,
+	0x4200100420080200, 0x8100020100080400, 0x0200040110886200, 0x0200008040220411,
+	0x0404800084400220, 0x0000401000402000, 0x0086001081220440, 0x0408800800100280,
+	0x000a001201040820, 0x8848800200840080, 0x4001000100040200, 0x0442000102105084,
+	0x9080010020804100, 0x0040404000201009, 0x0000808010002009, 0x2200090021d00100,
+	0x0008008008040080, 0x0004004002010040, 0x0011040008015042, 0x00000a0001768104,
+	0x0000800080204009, 0x2010004140002001, 0x9800200280100080, 0x1000100080080080,
+	0x0050500500080100, 0x0000020080040080, 0x0c10010400420810, 0x1040008200005104,
+	0x01808240088004a0, 0x0882804004802000, 0x0880402001001100, 0x0000100080800800,
+	0x2000480131001500, 0x0002000400800280, 0x0080020104000810, 0x80441044120000a1,
+	0x0000800040008020, 0x041040201000c000, 0x0001004020010010, 0x0800100100090021,
+	0x0004080004008080, 0x0010040002008080, 0x2012004881020004, 0x8300842444820011,
+	0x0088403882010200, 0x0820400080210100, 0x0110910040a00300, 0x0801100280080480,
+	0x0242009008200600, 0x1002000489500200, 0x0040800200010080, 0x0091800041000080,
+	0x000c91800020c101, 0x0a41104009802103, 0x000880401202210a, 0x0000300089142101,
+	0x8002002004100802, 0x30010002084c0007, 0x0888221800813004, 0x000008208044010a,
+}
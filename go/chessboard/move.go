@@ -0,0 +1,311 @@
+package chessboard
+
+// Move is a move from one square to another. Promotion names the piece a
+// pawn turns into on the last rank and is NoKind otherwise. Castling is
+// written as the king's two-square move, e.g. e1g1.
+type Move struct {
+	From, To  Square
+	Promotion Kind
+}
+
+// String returns the move in the long algebraic form used by UCI, e.g.
+// "e2e4" or "e7e8q".
+func (m Move) String() string {
+	s := m.From.String() + m.To.String()
+	if m.Promotion != NoKind {
+		s += string(pieceLetters[m.Promotion])
+	}
+	return s
+}
+
+// Undo holds what MakeMove changed, so UnmakeMove can put it back.
+type Undo struct {
+	move          Move
+	captured      Piece
+	capturedAt    Square
+	castling      CastlingRights
+	enPassant     Square
+	halfmoveClock int
+}
+
+var promotionKinds = []Kind{Queen, Rook, Bishop, Knight}
+
+// castlingMask keeps the rights that survive a move touching a square: a
+// king or rook leaving its square, or a rook being captured on it.
+var castlingMask [64]CastlingRights
+
+func init() {
+	for s := range castlingMask {
+		castlingMask[s] = WhiteKingside | WhiteQueenside | BlackKingside | BlackQueenside
+	}
+	castlingMask[NewSquare(4, 0)] &^= WhiteKingside | WhiteQueenside
+	castlingMask[NewSquare(7, 0)] &^= WhiteKingside
+	castlingMask[NewSquare(0, 0)] &^= WhiteQueenside
+	castlingMask[NewSquare(4, 7)] &^= BlackKingside | BlackQueenside
+	castlingMask[NewSquare(7, 7)] &^= BlackKingside
+	castlingMask[NewSquare(0, 7)] &^= BlackQueenside
+}
+
+// King returns the square of the king of the given color, or NoSquare if
+// there is none.
+func (p *Position) King(c Color) Square {
+	return p.Pieces(Piece{Kind: King, Color: c}).First()
+}
+
+// Attacked reports whether any piece of the given color attacks the square.
+func (p *Position) Attacked(s Square, by Color) bool {
+	occupied := p.Occupied()
+	side := p.byColor[by]
+	queens := p.byKind[Queen]
+	return PawnAttacks(by.Other(), s)&p.byKind[Pawn]&side != 0 ||
+		KnightAttacks(s)&p.byKind[Knight]&side != 0 ||
+		KingAttacks(s)&p.byKind[King]&side != 0 ||
+		BishopAttacks(s, occupied)&(p.byKind[Bishop]|queens)&side != 0 ||
+		RookAttacks(s, occupied)&(p.byKind[Rook]|queens)&side != 0
+}
+
+// InCheck reports whether the side to move is in check.
+func (p *Position) InCheck() bool {
+	king := p.King(p.SideToMove)
+	return king != NoSquare && p.Attacked(king, p.SideToMove.Other())
+}
+
+// PseudoLegalMoves returns the moves of the side to move that follow the
+// movement rules but may leave its own king in check. Castling is only
+// generated when the king does not start, pass or land on an attacked square.
+func (p *Position) PseudoLegalMoves() []Move {
+	return p.appendPseudoLegalMoves(make([]Move, 0, 48))
+}
+
+// LegalMoves returns the moves of the side to move that do not leave its
+// own king in check.
+func (p *Position) LegalMoves() []Move {
+	return p.appendLegalMoves(make([]Move, 0, 48))
+}
+
+func (p *Position) appendLegalMoves(moves []Move) []Move {
+	start := len(moves)
+	moves = p.appendPseudoLegalMoves(moves)
+	legal := moves[:start]
+	for _, m := range moves[start:] {
+		if p.isLegal(m) {
+			legal = append(legal, m)
+		}
+	}
+	return legal
+}
+
+// isLegal reports whether the pseudo-legal move m keeps the mover's king safe.
+func (p *Position) isLegal(m Move) bool {
+	us := p.SideToMove
+	u := p.MakeMove(m)
+	king := p.King(us)
+	legal := king == NoSquare || !p.Attacked(king, us.Other())
+	p.UnmakeMove(u)
+	return legal
+}
+
+func (p *Position) appendPseudoLegalMoves(moves []Move) []Move {
+	us, them := p.SideToMove, p.SideToMove.Other()
+	own, enemy := p.byColor[us], p.byColor[them]
+	occupied := own | enemy
+
+	forward, startRank, lastRank := 8, 1, 7
+	if us == Black {
+		forward, startRank, lastRank = -8, 6, 0
+	}
+	addPawn := func(from, to Square) {
+		if to.Rank() == lastRank {
+			for _, k := range promotionKinds {
+				moves = append(moves, Move{From: from, To: to, Promotion: k})
+			}
+			return
+		}
+		moves = append(moves, Move{From: from, To: to})
+	}
+	for from := range (p.byKind[Pawn] & own).Squares() {
+		to := from + Square(forward)
+		if !occupied.Has(to) {
+			addPawn(from, to)
+			if double := to + Square(forward); from.Rank() == startRank && !occupied.Has(double) {
+				moves = append(moves, Move{From: from, To: double})
+			}
+		}
+		targets := PawnAttacks(us, from) & enemy
+		if p.EnPassant != NoSquare && PawnAttacks(us, from).Has(p.EnPassant) {
+			targets |= SquareBit(p.EnPassant)
+		}
+		for to := range targets.Squares() {
+			addPawn(from, to)
+		}
+	}
+
+	for _, kind := range []Kind{Knight, Bishop, Rook, Queen, King} {
+		for from := range (p.byKind[kind] & own).Squares() {
+			var targets Bitboard
+			switch kind {
+			case Knight:
+				targets = KnightAttacks(from)
+			case Bishop:
+				targets = BishopAttacks(from, occupied)
+			case Rook:
+				targets = RookAttacks(from, occupied)
+			case Queen:
+				targets = QueenAttacks(from, occupied)
+			case King:
+				targets = KingAttacks(from)
+			}
+			for to := range (targets &^ own).Squares() {
+				moves = append(moves, Move{From: from, To: to})
+			}
+		}
+	}
+	return p.appendCastling(moves)
+}
+
+func (p *Position) appendCastling(moves []Move) []Move {
+	us, them := p.SideToMove, p.SideToMove.Other()
+	rank, kingside, queenside := 0, WhiteKingside, WhiteQueenside
+	if us == Black {
+		rank, kingside, queenside = 7, BlackKingside, BlackQueenside
+	}
+	king := NewSquare(4, rank)
+	if p.Castling&(kingside|queenside) == 0 || p.squares[king] != (Piece{Kind: King, Color: us}) ||
+		p.Attacked(king, them) {
+		return moves
+	}
+	rook := Piece{Kind: Rook, Color: us}
+	occupied := p.Occupied()
+	if p.Castling&kingside != 0 && p.squares[NewSquare(7, rank)] == rook &&
+		!occupied.Has(NewSquare(5, rank)) && !occupied.Has(NewSquare(6, rank)) &&
+		!p.Attacked(NewSquare(5, rank), them) && !p.Attacked(NewSquare(6, rank), them) {
+		moves = append(moves, Move{From: king, To: NewSquare(6, rank)})
+	}
+	if p.Castling&queenside != 0 && p.squares[NewSquare(0, rank)] == rook &&
+		!occupied.Has(NewSquare(1, rank)) && !occupied.Has(NewSquare(2, rank)) && !occupied.Has(NewSquare(3, rank)) &&
+		!p.Attacked(NewSquare(3, rank), them) && !p.Attacked(NewSquare(2, rank), them) {
+		moves = append(moves, Move{From: king, To: NewSquare(2, rank)})
+	}
+	return moves
+}
+
+// MakeMove plays a pseudo-legal move and returns what is needed to take it
+// back. It does not check the move; playing anything else leaves the
+// position in an undefined state.
+func (p *Position) MakeMove(m Move) Undo {
+	u := Undo{
+		move:          m,
+		capturedAt:    m.To,
+		castling:      p.Castling,
+		enPassant:     p.EnPassant,
+		halfmoveClock: p.HalfmoveClock,
+	}
+	piece := p.squares[m.From]
+	if piece.Kind == Pawn && m.To == p.EnPassant && m.From.File() != m.To.File() {
+		u.capturedAt = NewSquare(m.To.File(), m.From.Rank())
+	}
+	u.captured = p.squares[u.capturedAt]
+
+	p.Put(u.capturedAt, NoPiece)
+	p.Put(m.From, NoPiece)
+	if m.Promotion != NoKind {
+		p.Put(m.To, Piece{Kind: m.Promotion, Color: piece.Color})
+	} else {
+		p.Put(m.To, piece)
+	}
+	if piece.Kind == King && m.To.File()-m.From.File() == 2 {
+		p.moveRook(m.From.Rank(), 7, 5)
+	} else if piece.Kind == King && m.From.File()-m.To.File() == 2 {
+		p.moveRook(m.From.Rank(), 0, 3)
+	}
+
+	p.Castling &= castlingMask[m.From] & castlingMask[m.To]
+	p.EnPassant = NoSquare
+	if piece.Kind == Pawn && (m.To-m.From == 16 || m.From-m.To == 16) {
+		p.EnPassant = (m.From + m.To) / 2
+	}
+	p.HalfmoveClock++
+	if piece.Kind == Pawn || u.captured != NoPiece {
+		p.HalfmoveClock = 0
+	}
+	if p.SideToMove == Black {
+		p.FullmoveNumber++
+	}
+	p.SideToMove = p.SideToMove.Other()
+	return u
+}
+
+// UnmakeMove takes back the move MakeMove returned u for. Moves must be
+// taken back in the reverse order they were made.
+func (p *Position) UnmakeMove(u Undo) {
+	m := u.move
+	p.SideToMove = p.SideToMove.Other()
+	if p.SideToMove == Black {
+		p.FullmoveNumber--
+	}
+	piece := p.squares[m.To]
+	if m.Promotion != NoKind {
+		piece.Kind = Pawn
+	}
+	if piece.Kind == King && m.To.File()-m.From.File() == 2 {
+		p.moveRook(m.From.Rank(), 5, 7)
+	} else if piece.Kind == King && m.From.File()-m.To.File() == 2 {
+		p.moveRook(m.From.Rank(), 3, 0)
+	}
+	p.Put(m.To, NoPiece)
+	p.Put(m.From, piece)
+	p.Put(u.capturedAt, u.captured)
+	p.Castling = u.castling
+	p.EnPassant = u.enPassant
+	p.HalfmoveClock = u.halfmoveClock
+}
+
+func (p *Position) moveRook(rank, from, to int) {
+	rook := p.squares[NewSquare(from, rank)]
+	p.Put(NewSquare(from, rank), NoPiece)
+	p.Put(NewSquare(to, rank), rook)
+}
+
+// Perft counts the leaf nodes of the legal move tree to the given depth.
+// Comparing the counts with published values is the standard way of
+// checking a move generator. A depth below 1 counts the position itself.
+func (p *Position) Perft(depth int) int64 {
+	if depth <= 0 {
+		return 1
+	}
+	buffers := make([][]Move, depth)
+	return p.perft(depth, buffers)
+}
+
+func (p *Position) perft(depth int, buffers [][]Move) int64 {
+	if depth == 0 {
+		return 1
+	}
+	moves := p.appendLegalMoves(buffers[depth-1][:0])
+	buffers[depth-1] = moves
+	if depth == 1 {
+		return int64(len(moves))
+	}
+	var nodes int64
+	for i := 0; i < len(moves); i++ {
+		u := p.MakeMove(moves[i])
+		nodes += p.perft(depth-1, buffers)
+		p.UnmakeMove(u)
+	}
+	return nodes
+}
+
+// PerftDivide returns the perft count below each legal move, which helps
+// to find the move where a generator goes wrong.
+func (p *Position) PerftDivide(depth int) map[Move]int64 {
+	result := make(map[Move]int64)
+	if depth < 1 {
+		return result
+	}
+	for _, m := range p.LegalMoves() {
+		u := p.MakeMove(m)
+		result[m] = p.Perft(depth - 1)
+		p.UnmakeMove(u)
+	}
+	return result
+}
@@ -0,0 +1,194 @@
+package chessboard
+
+import (
+	"fmt"
+	"testing"
+)
+
+// Reference counts from https://www.chessprogramming.org/Perft_Results.
+var perftPositions = []struct {
+	name  string
+	fen   string
+	nodes []int64
+}{
+	{
+		name:  "start",
+		fen:   StartFEN,
+		nodes: []int64{20, 400, 8902, 197281},
+	},
+	{
+		name:  "kiwipete",
+		fen:   "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+		nodes: []int64{48, 2039, 97862, 4085603},
+	},
+	{
+		name:  "position 3",
+		fen:   "8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1",
+		nodes: []int64{14, 191, 2812, 43238, 674624},
+	},
+	{
+		name:  "position 4",
+		fen:   "r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1",
+		nodes: []int64{6, 264, 9467, 422333},
+	},
+	{
+		name:  "position 5",
+		fen:   "rnbq1k1r/pp1Pbppp/2p5/8/2B5/8/PPP1NnPP/RNBQK2R w KQ - 1 8",
+		nodes: []int64{44, 1486, 62379, 2103487},
+	},
+	{
+		name:  "position 6",
+		fen:   "r4rk1/1pp1qppp/p1np1n2/2b1p1B1/2B1P1b1/P1NP1N2/1PP1QPPP/R4RK1 w - - 0 10",
+		nodes: []int64{46, 2079, 89890},
+	},
+}
+
+func TestPerft(t *testing.T) {
+	for _, tc := range perftPositions {
+		for depth, want := range tc.nodes {
+			depth++
+			if testing.Short() && want > 100000 {
+				continue
+			}
+			t.Run(fmt.Sprintf("%s depth %d", tc.name, depth), func(t *testing.T) {
+				p, err := ParseFEN(tc.fen)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := p.Perft(depth); got != want {
+					t.Errorf("Perft(%d) = %d, want %d", depth, got, want)
+				}
+				if p.FEN() != tc.fen {
+					t.Errorf("Perft(%d) left the position at %q", depth, p.FEN())
+				}
+			})
+		}
+	}
+	p, _ := ParseFEN(StartFEN)
+	for _, depth := range []int{0, -1} {
+		if got := p.Perft(depth); got != 1 {
+			t.Errorf("Perft(%d) = %d, want 1", depth, got)
+		}
+	}
+}
+
+func TestPerftDivide(t *testing.T) {
+	p, _ := ParseFEN(StartFEN)
+	divide := p.PerftDivide(3)
+	var total int64
+	for _, n := range divide {
+		total += n
+	}
+	if len(divide) != 20 || total != 8902 {
+		t.Errorf("PerftDivide(3) has %d moves and %d nodes, want 20 and 8902", len(divide), total)
+	}
+	e2e4 := Move{From: NewSquare(4, 1), To: NewSquare(4, 3)}
+	if divide[e2e4] != 600 {
+		t.Errorf("PerftDivide(3)[e2e4] = %d, want 600", divide[e2e4])
+	}
+}
+
+func TestMakeMove(t *testing.T) {
+	testCases := []struct {
+		name string
+		fen  string
+		move Move
+		want string
+	}{
+		{
+			name: "double push sets en passant",
+			fen:  StartFEN,
+			move: Move{From: NewSquare(4, 1), To: NewSquare(4, 3)},
+			want: "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1",
+		},
+		{
+			name: "en passant capture",
+			fen:  "rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3",
+			move: Move{From: NewSquare(4, 4), To: NewSquare(5, 5)},
+			want: "rnbqkbnr/ppp1p1pp/5P2/3p4/8/8/PPPP1PPP/RNBQKBNR b KQkq - 0 3",
+		},
+		{
+			name: "kingside castling",
+			fen:  "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 3 10",
+			move: Move{From: NewSquare(4, 0), To: NewSquare(6, 0)},
+			want: "r3k2r/8/8/8/8/8/8/R4RK1 b kq - 4 10",
+		},
+		{
+			name: "queenside castling",
+			fen:  "r3k2r/8/8/8/8/8/8/R3K2R b KQkq - 3 10",
+			move: Move{From: NewSquare(4, 7), To: NewSquare(2, 7)},
+			want: "2kr3r/8/8/8/8/8/8/R3K2R w KQ - 4 11",
+		},
+		{
+			name: "rook capture removes castling right",
+			fen:  "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1",
+			move: Move{From: NewSquare(0, 0), To: NewSquare(0, 7)},
+			want: "R3k2r/8/8/8/8/8/8/4K2R b Kk - 0 1",
+		},
+		{
+			name: "capture promotion",
+			fen:  "1r2k3/P7/8/8/8/8/8/4K3 w - - 5 40",
+			move: Move{From: NewSquare(0, 6), To: NewSquare(1, 7), Promotion: Knight},
+			want: "1N2k3/8/8/8/8/8/8/4K3 b - - 0 40",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := ParseFEN(tc.fen)
+			if err != nil {
+				t.Fatal(err)
+			}
+			found := false
+			for _, m := range p.LegalMoves() {
+				found = found || m == tc.move
+			}
+			if !found {
+				t.Fatalf("LegalMoves() does not contain %v", tc.move)
+			}
+			u := p.MakeMove(tc.move)
+			if got := p.FEN(); got != tc.want {
+				t.Errorf("MakeMove(%v) = %q, want %q", tc.move, got, tc.want)
+			}
+			p.UnmakeMove(u)
+			if got := p.FEN(); got != tc.fen {
+				t.Errorf("UnmakeMove(%v) = %q, want %q", tc.move, got, tc.fen)
+			}
+		})
+	}
+}
+
+func TestCheckAndMate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		fen     string
+		inCheck bool
+		moves   int
+	}{
+		{name: "fool's mate", fen: "rnb1kbnr/pppp1ppp/8/4p3/6Pq/5P2/PPPPP2P/RNBQKBNR w KQkq - 1 3", inCheck: true, moves: 0},
+		{name: "stalemate", fen: "7k/5Q2/6K1/8/8/8/8/8 b - - 0 1", inCheck: false, moves: 0},
+		{name: "bare king", fen: "4k3/8/8/8/8/8/8/R3K3 b - - 0 1", inCheck: false, moves: 5},
+		{name: "pinned pawn", fen: "4k3/8/8/8/1b6/8/3P4/4K3 w - - 0 1", inCheck: false, moves: 4},
+		{name: "castling out of check", fen: "4k3/8/8/8/8/8/8/R3K2r w Q - 0 1", inCheck: true, moves: 3},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := ParseFEN(tc.fen)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := p.InCheck(); got != tc.inCheck {
+				t.Errorf("InCheck() = %v, want %v", got, tc.inCheck)
+			}
+			if got := p.LegalMoves(); len(got) != tc.moves {
+				t.Errorf("LegalMoves() = %v, want %d moves", got, tc.moves)
+			}
+		})
+	}
+}
+
+func BenchmarkPerftStart(b *testing.B) {
+	p, _ := ParseFEN(StartFEN)
+	for i := 0; i < b.N; i++ {
+		p.Perft(3)
+	}
+}
@@ -0,0 +1,611 @@
+package chessboard
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Tag is a PGN tag pair such as [Event "Casual game"].
+type Tag struct {
+	Name, Value string
+}
+
+// Game is a game read from or written to PGN.
+type Game struct {
+	Tags     []Tag
+	MainLine Variation
+	// Result is "1-0", "0-1", "1/2-1/2" or "*" for an unfinished game.
+	Result string
+}
+
+// Variation is a sequence of moves, with the comment written before the first one.
+type Variation struct {
+	Comment string
+	Moves   []AnnotatedMove
+}
+
+// AnnotatedMove is a move with the annotations that follow it in PGN.
+type AnnotatedMove struct {
+	Move Move
+	// NAGs are Numeric Annotation Glyphs; "!" and "?" suffixes are read as 1 to 6.
+	NAGs    []int
+	Comment string
+	// Variations are alternatives to this move, played from the position before it.
+	Variations []Variation
+}
+
+// Tag returns the value of the named tag, or "" if the game has none.
+func (g *Game) Tag(name string) string {
+	for _, t := range g.Tags {
+		if t.Name == name {
+			return t.Value
+		}
+	}
+	return ""
+}
+
+// SetTag sets the value of the named tag, adding it if needed.
+func (g *Game) SetTag(name, value string) {
+	for i := range g.Tags {
+		if g.Tags[i].Name == name {
+			g.Tags[i].Value = value
+			return
+		}
+	}
+	g.Tags = append(g.Tags, Tag{Name: name, Value: value})
+}
+
+// StartPosition returns the position the game starts from: the one given
+// by its FEN tag, or the standard starting position.
+func (g *Game) StartPosition() (*Position, error) {
+	if fen := g.Tag("FEN"); fen != "" {
+		return ParseFEN(fen)
+	}
+	return ParseFEN(StartFEN)
+}
+
+// ErrInvalidPGN is matched by every PGNError.
+var ErrInvalidPGN = errors.New("invalid PGN")
+
+// PGNError describes what is wrong with PGN input and where.
+type PGNError struct {
+	// Line and Column are 1-based and point at the start of the bad token.
+	Line, Column int
+	Reason       string
+}
+
+func (e *PGNError) Error() string {
+	return fmt.Sprintf("%v: line %d column %d: %s", ErrInvalidPGN, e.Line, e.Column, e.Reason)
+}
+
+// Is makes errors.Is(err, ErrInvalidPGN) match.
+func (e *PGNError) Is(target error) bool {
+	return target == ErrInvalidPGN
+}
+
+type pgnTokenKind int
+
+const (
+	tokenEOF pgnTokenKind = iota
+	tokenSymbol
+	tokenString
+	tokenPeriod
+	tokenStar
+	tokenOpenBracket
+	tokenCloseBracket
+	tokenOpenParen
+	tokenCloseParen
+	tokenComment
+	tokenNAG
+)
+
+type pgnToken struct {
+	kind         pgnTokenKind
+	text         string
+	line, column int
+}
+
+// suffixNAGs maps move suffix annotations to their NAG numbers.
+var suffixNAGs = map[string]int{"!": 1, "?": 2, "!!": 3, "??": 4, "!?": 5, "?!": 6}
+
+var gameResults = []string{"1-0", "0-1", "1/2-1/2", "*"}
+
+// PGNReader reads games one at a time from a PGN stream, so databases of
+// any size can be processed in constant memory.
+type PGNReader struct {
+	r                 *bufio.Reader
+	line, column      int
+	prevLine, prevCol int
+	peeked            *pgnToken
+	recover           bool
+}
+
+// NewPGNReader returns a reader of the games in r.
+func NewPGNReader(r io.Reader) *PGNReader {
+	return &PGNReader{r: bufio.NewReader(r), line: 1}
+}
+
+// Next returns the next game. It returns io.EOF once there are no more
+// games, and a *PGNError for a malformed game; the following call then
+// skips to the next game.
+func (r *PGNReader) Next() (*Game, error) {
+	if r.recover {
+		if err := r.skipGame(); err != nil {
+			return nil, err
+		}
+		r.recover = false
+	}
+	g, err := r.readGame()
+	if err != nil {
+		var pgnErr *PGNError
+		if errors.As(err, &pgnErr) {
+			r.recover = true
+		}
+		return nil, err
+	}
+	return g, nil
+}
+
+func (r *PGNReader) readGame() (*Game, error) {
+	t, err := r.token()
+	if err != nil {
+		return nil, err
+	}
+	if t.kind == tokenEOF {
+		return nil, io.EOF
+	}
+	g := &Game{Result: "*"}
+	var fenTag pgnToken
+	for t.kind == tokenOpenBracket {
+		name, err := r.expect(tokenSymbol, "tag name")
+		if err != nil {
+			return nil, err
+		}
+		value, err := r.expect(tokenString, "tag value")
+		if err != nil {
+			return nil, err
+		}
+		if _, err := r.expect(tokenCloseBracket, "]"); err != nil {
+			return nil, err
+		}
+		if name.text == "FEN" {
+			fenTag = value
+		}
+		g.Tags = append(g.Tags, Tag{Name: name.text, Value: value.text})
+		if t, err = r.token(); err != nil {
+			return nil, err
+		}
+	}
+	r.peeked = &t
+
+	p, err := g.StartPosition()
+	if err != nil {
+		return nil, errorAt(fenTag, "%v", err)
+	}
+	g.MainLine, err = r.readVariation(p, g, true)
+	if err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// readVariation reads moves played from p until the end of the variation,
+// or until the game result when top is set.
+func (r *PGNReader) readVariation(p *Position, g *Game, top bool) (Variation, error) {
+	var v Variation
+	var before Position
+	last := func() *AnnotatedMove { return &v.Moves[len(v.Moves)-1] }
+	for {
+		t, err := r.token()
+		if err != nil {
+			return v, err
+		}
+		switch t.kind {
+		case tokenPeriod:
+		case tokenComment:
+			if len(v.Moves) == 0 {
+				v.Comment = joinComment(v.Comment, t.text)
+			} else {
+				last().Comment = joinComment(last().Comment, t.text)
+			}
+		case tokenNAG:
+			if len(v.Moves) == 0 {
+				return v, errorAt(t, "annotation before any move")
+			}
+			nag, ok := suffixNAGs[t.text]
+			if !ok {
+				n, err := strconv.Atoi(t.text[1:])
+				if err != nil || n < 0 || n > 255 {
+					return v, errorAt(t, "invalid annotation %q", t.text)
+				}
+				nag = n
+			}
+			last().NAGs = append(last().NAGs, nag)
+		case tokenOpenParen:
+			if len(v.Moves) == 0 {
+				return v, errorAt(t, "variation before any move")
+			}
+			q := before
+			sub, err := r.readVariation(&q, g, false)
+			if err != nil {
+				return v, err
+			}
+			last().Variations = append(last().Variations, sub)
+		case tokenCloseParen:
+			if top {
+				return v, errorAt(t, "unmatched )")
+			}
+			return v, nil
+		case tokenStar, tokenSymbol:
+			if slices.Contains(gameResults, t.text) {
+				if !top {
+					return v, errorAt(t, "game result inside a variation")
+				}
+				g.Result = t.text
+				return v, nil
+			}
+			if strings.Trim(t.text, "0123456789") == "" {
+				continue // move number
+			}
+			m, err := p.ParseSAN(t.text)
+			if err != nil {
+				return v, errorAt(t, "%v", err)
+			}
+			before = *p
+			p.MakeMove(m)
+			v.Moves = append(v.Moves, AnnotatedMove{Move: m})
+		case tokenEOF:
+			return v, errorAt(t, "unexpected end of input, want a game result")
+		default:
+			return v, errorAt(t, "unexpected %q in move text", t.text)
+		}
+	}
+}
+
+// skipGame discards input up to and including the next game result, or
+// up to the next tag pair at the start of a line.
+func (r *PGNReader) skipGame() error {
+	for {
+		t, err := r.token()
+		if err != nil {
+			return err
+		}
+		switch {
+		case t.kind == tokenEOF:
+			r.peeked = &t
+			return nil
+		case t.kind == tokenOpenBracket && t.column == 1:
+			r.peeked = &t
+			return nil
+		case (t.kind == tokenSymbol || t.kind == tokenStar) && slices.Contains(gameResults, t.text):
+			return nil
+		}
+	}
+}
+
+func (r *PGNReader) expect(kind pgnTokenKind, what string) (pgnToken, error) {
+	t, err := r.token()
+	if err != nil {
+		return t, err
+	}
+	if t.kind != kind {
+		return t, errorAt(t, "got %q, want %s", t.text, what)
+	}
+	return t, nil
+}
+
+func errorAt(t pgnToken, format string, args ...any) error {
+	return &PGNError{Line: t.line, Column: t.column, Reason: fmt.Sprintf(format, args...)}
+}
+
+func joinComment(a, b string) string {
+	if a == "" {
+		return b
+	}
+	return a + " " + b
+}
+
+func (r *PGNReader) readByte() (byte, error) {
+	c, err := r.r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	r.prevLine, r.prevCol = r.line, r.column
+	if c == '\n' {
+		r.line, r.column = r.line+1, 0
+	} else {
+		r.column++
+	}
+	return c, nil
+}
+
+func (r *PGNReader) unreadByte() {
+	r.r.UnreadByte()
+	r.line, r.column = r.prevLine, r.prevCol
+}
+
+func isSymbolByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.IndexByte("_+#=:-/", c) >= 0
+}
+
+// token returns the next token. Read errors other than io.EOF are returned
+// as they are; the end of input is a tokenEOF.
+func (r *PGNReader) token() (pgnToken, error) {
+	if r.peeked != nil {
+		t := *r.peeked
+		r.peeked = nil
+		return t, nil
+	}
+	for {
+		c, err := r.readByte()
+		if err == io.EOF {
+			return pgnToken{kind: tokenEOF, text: "end of input", line: r.line, column: r.column + 1}, nil
+		}
+		if err != nil {
+			return pgnToken{}, err
+		}
+		t := pgnToken{text: string(c), line: r.line, column: r.column}
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			continue
+		case c == '%' && t.column == 1:
+			// An escaped line, ignored by every PGN reader.
+			if _, err := r.readUntil('\n'); err != nil {
+				return t, err
+			}
+			continue
+		case c == '[':
+			t.kind = tokenOpenBracket
+		case c == ']':
+			t.kind = tokenCloseBracket
+		case c == '(':
+			t.kind = tokenOpenParen
+		case c == ')':
+			t.kind = tokenCloseParen
+		case c == '*':
+			t.kind = tokenStar
+		case c == '.':
+			t.kind = tokenPeriod
+		case c == '{':
+			t.kind = tokenComment
+			text, err := r.readUntil('}')
+			if err == io.EOF {
+				return t, errorAt(t, "unterminated comment")
+			}
+			t.text = strings.Join(strings.Fields(text), " ")
+			return t, err
+		case c == ';':
+			t.kind = tokenComment
+			text, err := r.readUntil('\n')
+			t.text = strings.TrimSpace(text)
+			return t, err
+		case c == '"':
+			t.kind = tokenString
+			return t, r.readString(&t)
+		case c == '$' || c == '!' || c == '?':
+			t.kind = tokenNAG
+			return t, r.readWhile(&t, func(b byte) bool {
+				if c == '$' {
+					return b >= '0' && b <= '9'
+				}
+				return b == '!' || b == '?'
+			})
+		case isSymbolByte(c):
+			t.kind = tokenSymbol
+			return t, r.readWhile(&t, isSymbolByte)
+		default:
+			return t, errorAt(t, "unexpected character %q", c)
+		}
+		return t, nil
+	}
+}
+
+// readUntil reads up to and including delim, which is dropped. The end of
+// input ends the text too, and is reported as io.EOF only when delim is '}'.
+func (r *PGNReader) readUntil(delim byte) (string, error) {
+	var b strings.Builder
+	for {
+		c, err := r.readByte()
+		if err == io.EOF && delim == '\n' {
+			return b.String(), nil
+		}
+		if err != nil {
+			return b.String(), err
+		}
+		if c == delim {
+			return b.String(), nil
+		}
+		b.WriteByte(c)
+	}
+}
+
+func (r *PGNReader) readWhile(t *pgnToken, ok func(byte) bool) error {
+	var b strings.Builder
+	b.WriteString(t.text)
+	for {
+		c, err := r.readByte()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if !ok(c) {
+			r.unreadByte()
+			break
+		}
+		b.WriteByte(c)
+	}
+	t.text = b.String()
+	return nil
+}
+
+func (r *PGNReader) readString(t *pgnToken) error {
+	var b strings.Builder
+	for {
+		c, err := r.readByte()
+		if err == io.EOF || c == '\n' {
+			return errorAt(*t, "unterminated string")
+		}
+		if err != nil {
+			return err
+		}
+		switch c {
+		case '"':
+			t.text = b.String()
+			return nil
+		case '\\':
+			if c, err = r.readByte(); err != nil {
+				return errorAt(*t, "unterminated string")
+			}
+		}
+		b.WriteByte(c)
+	}
+}
+
+// sevenTagRoster lists the tags every exported game has, in export order.
+var sevenTagRoster = []string{"Event", "Site", "Date", "Round", "White", "Black", "Result"}
+
+var rosterDefaults = map[string]string{"Date": "????.??.??"}
+
+// pgnLineLength is the longest line WritePGN writes, unless a single
+// token is longer.
+const pgnLineLength = 79
+
+// WritePGN writes the games in PGN export format: the seven tag roster
+// first, then the other tags by name, then the move text in SAN wrapped
+// at 79 columns. Moves that are not legal are reported as ErrIllegalMove.
+func WritePGN(w io.Writer, games ...*Game) error {
+	bw := bufio.NewWriter(w)
+	for _, g := range games {
+		if err := writeGame(bw, g); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+func writeGame(w *bufio.Writer, g *Game) error {
+	result := g.Result
+	if result == "" {
+		result = "*"
+	}
+	for _, name := range sevenTagRoster {
+		value := g.Tag(name)
+		switch {
+		case name == "Result":
+			value = result
+		case value == "" && rosterDefaults[name] != "":
+			value = rosterDefaults[name]
+		case value == "":
+			value = "?"
+		}
+		writeTag(w, name, value)
+	}
+	var others []Tag
+	for _, t := range g.Tags {
+		if !slices.Contains(sevenTagRoster, t.Name) {
+			others = append(others, t)
+		}
+	}
+	slices.SortStableFunc(others, func(a, b Tag) int { return strings.Compare(a.Name, b.Name) })
+	for _, t := range others {
+		writeTag(w, t.Name, t.Value)
+	}
+	w.WriteByte('\n')
+
+	p, err := g.StartPosition()
+	if err != nil {
+		return err
+	}
+	var words pgnWords
+	if err := words.variation(p, g.MainLine); err != nil {
+		return err
+	}
+	words.add(result)
+	column := 0
+	for _, word := range words.list {
+		if column > 0 && column+1+len(word) > pgnLineLength {
+			w.WriteByte('\n')
+			column = 0
+		}
+		if column > 0 {
+			w.WriteByte(' ')
+			column++
+		}
+		w.WriteString(word)
+		column += len(word)
+	}
+	_, err = w.WriteString("\n\n")
+	return err
+}
+
+func writeTag(w *bufio.Writer, name, value string) {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `"`, `\"`)
+	fmt.Fprintf(w, "[%s \"%s\"]\n", name, value)
+}
+
+// pgnWords collects move text as words to be wrapped. Parentheses stick
+// to the word they enclose.
+type pgnWords struct {
+	list []string
+	open int
+}
+
+func (ws *pgnWords) add(word string) {
+	ws.list = append(ws.list, strings.Repeat("(", ws.open)+word)
+	ws.open = 0
+}
+
+func (ws *pgnWords) comment(text string) {
+	fields := strings.Fields(strings.ReplaceAll(text, "}", ""))
+	if len(fields) == 0 {
+		return
+	}
+	fields[0] = "{" + fields[0]
+	fields[len(fields)-1] += "}"
+	for _, f := range fields {
+		ws.add(f)
+	}
+}
+
+func (ws *pgnWords) variation(p *Position, v Variation) error {
+	ws.comment(v.Comment)
+	number := true
+	for _, am := range v.Moves {
+		if !slices.Contains(p.LegalMoves(), am.Move) {
+			return fmt.Errorf("%w: %v in %s", ErrIllegalMove, am.Move, p.FEN())
+		}
+		if p.SideToMove == White {
+			ws.add(strconv.Itoa(p.FullmoveNumber) + ".")
+		} else if number {
+			ws.add(strconv.Itoa(p.FullmoveNumber) + "...")
+		}
+		ws.add(p.SAN(am.Move))
+		for _, nag := range am.NAGs {
+			ws.add("$" + strconv.Itoa(nag))
+		}
+		ws.comment(am.Comment)
+		before := *p
+		for _, sub := range am.Variations {
+			if len(sub.Moves) == 0 {
+				continue
+			}
+			ws.open++
+			q := before
+			if err := ws.variation(&q, sub); err != nil {
+				return err
+			}
+			ws.list[len(ws.list)-1] += ")"
+		}
+		number = am.Comment != "" || len(am.Variations) > 0
+		p.MakeMove(am.Move)
+	}
+	return nil
+}
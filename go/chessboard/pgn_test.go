@@ -0,0 +1,276 @@
+package chessboard
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestSAN(t *testing.T) {
+	testCases := []struct {
+		fen  string
+		move string
+		want string
+	}{
+		{fen: StartFEN, move: "g1f3", want: "Nf3"},
+		{fen: StartFEN, move: "e2e4", want: "e4"},
+		{fen: "rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3", move: "e5f6", want: "exf6"},
+		{fen: "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", move: "e1g1", want: "O-O"},
+		{fen: "r3k2r/8/8/8/8/8/8/R3K2R b KQkq - 0 1", move: "e8c8", want: "O-O-O"},
+		{fen: "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", move: "a1a8", want: "Rxa8+"},
+		{fen: "1r2k3/P7/8/8/8/8/8/4K3 w - - 0 1", move: "a7b8q", want: "axb8=Q+"},
+		{fen: "4k3/8/8/8/8/8/4K3/R6R w - - 0 1", move: "a1d1", want: "Rad1"},
+		{fen: "4k3/8/8/R7/8/8/8/R3K3 w - - 0 1", move: "a1a3", want: "R1a3"},
+		{fen: "4k3/8/8/8/2Q1Q3/8/4Q3/4K3 w - - 0 1", move: "e4d3", want: "Qe4d3+"},
+		{fen: "rnbqkbnr/pppp1ppp/8/4p3/6P1/5P2/PPPPP2P/RNBQKBNR b KQkq - 0 2", move: "d8h4", want: "Qh4#"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.want, func(t *testing.T) {
+			p, err := ParseFEN(tc.fen)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var m Move
+			for _, legal := range p.LegalMoves() {
+				if legal.String() == tc.move {
+					m = legal
+				}
+			}
+			if got := p.SAN(m); got != tc.want {
+				t.Errorf("SAN(%s) = %q, want %q", tc.move, got, tc.want)
+			}
+			parsed, err := p.ParseSAN(tc.want)
+			if err != nil || parsed != m {
+				t.Errorf("ParseSAN(%q) = %v, %v, want %v", tc.want, parsed, err, m)
+			}
+		})
+	}
+}
+
+func TestParseSANAllMoves(t *testing.T) {
+	for _, tc := range perftPositions {
+		p, _ := ParseFEN(tc.fen)
+		for _, m := range p.LegalMoves() {
+			san := p.SAN(m)
+			if got, err := p.ParseSAN(san); err != nil || got != m {
+				t.Errorf("%s: ParseSAN(SAN(%v) = %q) = %v, %v", tc.name, m, san, got, err)
+			}
+		}
+	}
+}
+
+func TestParseSANLenient(t *testing.T) {
+	p, _ := ParseFEN("1r2k3/P7/8/8/8/8/8/R3K2R w KQ - 0 1")
+	for san, want := range map[string]string{"0-0": "e1g1", "O-O-O!?": "e1c1", "axb8Q": "a7b8q", "a8=N": "a7a8n", "Rhf1": "h1f1"} {
+		if m, err := p.ParseSAN(san); err != nil || m.String() != want {
+			t.Errorf("ParseSAN(%q) = %v, %v, want %s", san, m, err, want)
+		}
+	}
+}
+
+func TestParseSANErrors(t *testing.T) {
+	testCases := []struct {
+		fen  string
+		san  string
+		want error
+	}{
+		{fen: StartFEN, san: "e5", want: ErrIllegalMove},
+		{fen: StartFEN, san: "Ke2", want: ErrIllegalMove},
+		{fen: StartFEN, san: "xyz", want: ErrInvalidSAN},
+		{fen: StartFEN, san: "N", want: ErrInvalidSAN},
+		{fen: StartFEN, san: "e2e4e", want: ErrInvalidSAN},
+		{fen: "4k3/8/8/8/8/8/4K3/R6R w - - 0 1", san: "Rd1", want: ErrAmbiguousMove},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.san, func(t *testing.T) {
+			p, _ := ParseFEN(tc.fen)
+			if _, err := p.ParseSAN(tc.san); !errors.Is(err, tc.want) {
+				t.Errorf("ParseSAN(%q) error = %v, want %v", tc.san, err, tc.want)
+			}
+		})
+	}
+}
+
+const annotatedPGN = `[Event "Club  \"Open\""]
+[White "Anderssen"]
+[Black "Kieseritzky"]
+[Annotator "Someone"]
+[ECO "C33"]
+% escaped line
+{The immortal game.} 1.e4 e5 2.f4 exf4 3.Bc4 Qh4+ 4.Kf1 b5!? 5.Bxb5 $2 Nf6
+(5...Qf6 {A quieter line} (5...c6 6.Ba4) 6.Nc3) 6.Nf3 ; line comment
+Qh6 1-0
+`
+
+const canonicalPGN = `[Event "Club  \"Open\""]
+[Site "?"]
+[Date "????.??.??"]
+[Round "?"]
+[White "Anderssen"]
+[Black "Kieseritzky"]
+[Result "1-0"]
+[Annotator "Someone"]
+[ECO "C33"]
+
+{The immortal game.} 1. e4 e5 2. f4 exf4 3. Bc4 Qh4+ 4. Kf1 b5 $5 5. Bxb5 $2
+Nf6 (5... Qf6 {A quieter line} (5... c6 6. Ba4) 6. Nc3) 6. Nf3 {line comment}
+6... Qh6 1-0
+
+`
+
+func TestPGNReadWrite(t *testing.T) {
+	r := NewPGNReader(strings.NewReader(annotatedPGN))
+	g, err := r.Next()
+	if err != nil {
+		t.Fatalf("Next() error = %v", err)
+	}
+	if g.Tag("White") != "Anderssen" || g.Tag("Event") != `Club  "Open"` || g.Result != "1-0" {
+		t.Errorf("Next() tags = %+v, result %q", g.Tags, g.Result)
+	}
+	moves := g.MainLine.Moves
+	if len(moves) != 12 || g.MainLine.Comment != "The immortal game." {
+		t.Fatalf("Next() main line = %+v", g.MainLine)
+	}
+	if nags := moves[7].NAGs; len(nags) != 1 || nags[0] != 5 {
+		t.Errorf("b5!? NAGs = %v, want [5]", nags)
+	}
+	variations := moves[9].Variations
+	if len(variations) != 1 || len(variations[0].Moves) != 2 || len(variations[0].Moves[0].Variations) != 1 {
+		t.Errorf("variations after Nf6 = %+v", variations)
+	}
+	if _, err := r.Next(); err != io.EOF {
+		t.Errorf("Next() at the end error = %v, want io.EOF", err)
+	}
+
+	var b strings.Builder
+	if err := WritePGN(&b, g); err != nil {
+		t.Fatalf("WritePGN() error = %v", err)
+	}
+	if b.String() != canonicalPGN {
+		t.Errorf("WritePGN() =\n%s\nwant\n%s", b.String(), canonicalPGN)
+	}
+
+	again, err := NewPGNReader(strings.NewReader(b.String())).Next()
+	if err != nil {
+		t.Fatalf("reading WritePGN() output: %v", err)
+	}
+	var c strings.Builder
+	WritePGN(&c, again)
+	if c.String() != canonicalPGN {
+		t.Errorf("WritePGN() is not stable:\n%s", c.String())
+	}
+}
+
+func TestPGNFromFEN(t *testing.T) {
+	in := `[FEN "4k3/8/8/8/8/8/8/R3K3 b Q - 0 30"]
+[SetUp "1"]
+
+30... Kd7 31. O-O-O+ Kc6 *
+`
+	g, err := NewPGNReader(strings.NewReader(in)).Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	if err := WritePGN(&b, g); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(b.String(), "\n\n30... Kd7 31. O-O-O+ Kc6 *\n\n") {
+		t.Errorf("WritePGN() =\n%s", b.String())
+	}
+
+	g.MainLine.Moves = append(g.MainLine.Moves, AnnotatedMove{Move: Move{From: NewSquare(0, 0), To: NewSquare(0, 7)}})
+	if err := WritePGN(io.Discard, g); !errors.Is(err, ErrIllegalMove) {
+		t.Errorf("WritePGN() with an illegal move error = %v, want ErrIllegalMove", err)
+	}
+}
+
+func TestPGNErrors(t *testing.T) {
+	testCases := []struct {
+		name   string
+		pgn    string
+		line   int
+		column int
+	}{
+		{name: "illegal move", pgn: "[Event \"x\"]\n\n1. e4 e5\n2. Ke3 *\n", line: 4, column: 4},
+		{name: "unterminated tag", pgn: "[Event \"x]\n1. e4 *\n", line: 1, column: 8},
+		{name: "missing result", pgn: "1. e4 e5", line: 1, column: 9},
+		{name: "unmatched paren", pgn: "1. e4 ) *", line: 1, column: 7},
+		{name: "result in variation", pgn: "1. e4 (1. d4 1-0) *", line: 1, column: 14},
+		{name: "bad FEN", pgn: "[FEN \"8/8 w - -\"]\n*\n", line: 1, column: 6},
+		{name: "unterminated comment", pgn: "1. e4 {never closed", line: 1, column: 7},
+		{name: "bad character", pgn: "1. e4 @ *", line: 1, column: 7},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewPGNReader(strings.NewReader(tc.pgn)).Next()
+			var pgnErr *PGNError
+			if !errors.As(err, &pgnErr) || !errors.Is(err, ErrInvalidPGN) {
+				t.Fatalf("Next() error = %v, want a *PGNError", err)
+			}
+			if pgnErr.Line != tc.line || pgnErr.Column != tc.column {
+				t.Errorf("Next() error at %d:%d, want %d:%d: %v", pgnErr.Line, pgnErr.Column, tc.line, tc.column, err)
+			}
+		})
+	}
+}
+
+func TestPGNRecovers(t *testing.T) {
+	in := "[Event \"1\"]\n1. e4 *\n\n[Event \"2\"]\n1. e5 e4 *\n\n[Event \"3\"]\n1. d4 d5 1/2-1/2\n"
+	r := NewPGNReader(strings.NewReader(in))
+	var events []string
+	var errs int
+	for {
+		g, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			errs++
+			continue
+		}
+		events = append(events, g.Tag("Event"))
+	}
+	if errs != 1 || strings.Join(events, ",") != "1,3" {
+		t.Errorf("read games %v with %d errors, want 1,3 with 1 error", events, errs)
+	}
+}
+
+// repeatReader yields the same text n times without holding all of it.
+type repeatReader struct {
+	text string
+	n    int
+	rest string
+}
+
+func (r *repeatReader) Read(b []byte) (int, error) {
+	if r.rest == "" {
+		if r.n == 0 {
+			return 0, io.EOF
+		}
+		r.n--
+		r.rest = r.text
+	}
+	n := copy(b, r.rest)
+	r.rest = r.rest[n:]
+	return n, nil
+}
+
+func TestPGNStream(t *testing.T) {
+	r := NewPGNReader(&repeatReader{text: annotatedPGN + "\n", n: 500})
+	games := 0
+	for {
+		_, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("game %d: %v", games+1, err)
+		}
+		games++
+	}
+	if games != 500 {
+		t.Errorf("read %d games, want 500", games)
+	}
+}
@@ -0,0 +1,419 @@
+package chessboard
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// StartFEN is the FEN of the standard starting position.
+const StartFEN = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+
+// Color is the side a piece belongs to.
+type Color uint8
+
+const (
+	White Color = iota
+	Black
+)
+
+// Other returns the opposing color.
+func (c Color) Other() Color {
+	return c ^ 1
+}
+
+func (c Color) String() string {
+	if c == White {
+		return "white"
+	}
+	return "black"
+}
+
+// Kind is the type of a piece. NoKind marks an empty square.
+type Kind uint8
+
+const (
+	NoKind Kind = iota
+	Pawn
+	Knight
+	Bishop
+	Rook
+	Queen
+	King
+)
+
+// Piece is a piece of a given kind and color. The zero value is no piece.
+type Piece struct {
+	Kind  Kind
+	Color Color
+}
+
+// NoPiece is the content of an empty square.
+var NoPiece = Piece{}
+
+const pieceLetters = " pnbrqk"
+
+// Letter returns the FEN letter of the piece: uppercase for white,
+// lowercase for black, and a space for no piece.
+func (p Piece) Letter() byte {
+	l := pieceLetters[p.Kind]
+	if p.Color == White && p.Kind != NoKind {
+		l -= 'a' - 'A'
+	}
+	return l
+}
+
+func (p Piece) String() string {
+	return string(p.Letter())
+}
+
+// pieceFromLetter parses a FEN piece letter.
+func pieceFromLetter(l byte) (Piece, bool) {
+	color := Black
+	if l >= 'A' && l <= 'Z' {
+		color = White
+		l += 'a' - 'A'
+	}
+	i := strings.IndexByte(pieceLetters, l)
+	if i <= 0 {
+		return NoPiece, false
+	}
+	return Piece{Kind: Kind(i), Color: color}, true
+}
+
+// Square is a square of the board, from A1 = 0 to H8 = 63, going along
+// each rank before moving up to the next one.
+type Square int8
+
+// NoSquare marks the absence of a square, e.g. no en-passant target.
+const NoSquare Square = -1
+
+// NewSquare returns the square on the given file (0 for A) and rank (0 for 1).
+func NewSquare(file, rank int) Square {
+	return Square(rank*8 + file)
+}
+
+// File returns the file of the square, 0 for A.
+func (s Square) File() int {
+	return int(s) % 8
+}
+
+// Rank returns the rank of the square, 0 for rank 1.
+func (s Square) Rank() int {
+	return int(s) / 8
+}
+
+func (s Square) String() string {
+	if s < 0 || s > 63 {
+		return "-"
+	}
+	return string([]byte{byte('a' + s.File()), byte('1' + s.Rank())})
+}
+
+// ParseSquare parses algebraic coordinates such as "e4". Files may be
+// given in either case.
+func ParseSquare(name string) (Square, error) {
+	if len(name) != 2 {
+		return NoSquare, fmt.Errorf("invalid square %q", name)
+	}
+	file := name[0] | ('a' - 'A')
+	if file < 'a' || file > 'h' || name[1] < '1' || name[1] > '8' {
+		return NoSquare, fmt.Errorf("invalid square %q", name)
+	}
+	return NewSquare(int(file-'a'), int(name[1]-'1')), nil
+}
+
+// CastlingRights records which castling moves are still allowed.
+type CastlingRights uint8
+
+const (
+	WhiteKingside CastlingRights = 1 << iota
+	WhiteQueenside
+	BlackKingside
+	BlackQueenside
+)
+
+func (c CastlingRights) String() string {
+	if c == 0 {
+		return "-"
+	}
+	var b strings.Builder
+	for i, l := range "KQkq" {
+		if c&(1<<i) != 0 {
+			b.WriteRune(l)
+		}
+	}
+	return b.String()
+}
+
+// Position is a chess position: what stands on every square, whose turn
+// it is, and the state needed for castling, en passant and the draw rules.
+type Position struct {
+	squares [64]Piece
+	// byColor and byKind mirror squares as bitboards and are kept in step by Put.
+	byColor [2]Bitboard
+	byKind  [7]Bitboard
+	// pieceHash is the Zobrist hash of the pieces alone, also kept by Put.
+	pieceHash  uint64
+	SideToMove Color
+	Castling   CastlingRights
+	// EnPassant is the square a pawn skipped on its last move, or NoSquare.
+	EnPassant Square
+	// HalfmoveClock counts halfmoves since the last capture or pawn move.
+	HalfmoveClock int
+	// FullmoveNumber starts at 1 and goes up after every black move.
+	FullmoveNumber int
+}
+
+// NewPosition returns an empty board with white to move.
+func NewPosition() *Position {
+	return &Position{EnPassant: NoSquare, FullmoveNumber: 1}
+}
+
+// Piece returns what stands on the square.
+func (p *Position) Piece(s Square) Piece {
+	return p.squares[s]
+}
+
+// Put places a piece on the square, replacing whatever stood there.
+// Putting NoPiece clears the square.
+func (p *Position) Put(s Square, piece Piece) {
+	bit := SquareBit(s)
+	if old := p.squares[s]; old != NoPiece {
+		p.byColor[old.Color] &^= bit
+		p.byKind[old.Kind] &^= bit
+		p.pieceHash ^= pieceKeys[old.Color][old.Kind][s]
+	}
+	p.squares[s] = piece
+	if piece != NoPiece {
+		p.byColor[piece.Color] |= bit
+		p.byKind[piece.Kind] |= bit
+		p.pieceHash ^= pieceKeys[piece.Color][piece.Kind][s]
+	}
+}
+
+// Occupied returns the set of occupied squares.
+func (p *Position) Occupied() Bitboard {
+	return p.byColor[White] | p.byColor[Black]
+}
+
+// Side returns the squares holding pieces of the given color.
+func (p *Position) Side(c Color) Bitboard {
+	return p.byColor[c]
+}
+
+// Pieces returns the squares holding the given piece.
+func (p *Position) Pieces(piece Piece) Bitboard {
+	return p.byKind[piece.Kind] & p.byColor[piece.Color]
+}
+
+// CountInFile returns how many squares are occupied within the given file,
+// named "A" to "H".
+func (p *Position) CountInFile(file string) int {
+	return p.Occupied().CountInFile(file)
+}
+
+// CountInRank returns how many squares are occupied within the given rank.
+func (p *Position) CountInRank(rank int) int {
+	return p.Occupied().CountInRank(rank)
+}
+
+// CountOccupied returns how many squares are occupied.
+func (p *Position) CountOccupied() int {
+	return p.Occupied().Count()
+}
+
+func fileIndex(file string) (int, bool) {
+	if len(file) != 1 || file[0] < 'A' || file[0] > 'H' {
+		return 0, false
+	}
+	return int(file[0] - 'A'), true
+}
+
+// PositionFromChessboard converts an occupancy map into a position. The map
+// does not say what stands on a square, so every occupied square gets the
+// placeholder piece.
+func PositionFromChessboard(cb Chessboard, placeholder Piece) *Position {
+	p := NewPosition()
+	for s := range BitboardFromChessboard(cb).Squares() {
+		p.Put(s, placeholder)
+	}
+	return p
+}
+
+// Chessboard converts the position into an occupancy map with all eight files.
+func (p *Position) Chessboard() Chessboard {
+	return p.Occupied().Chessboard()
+}
+
+// ErrInvalidFEN is matched by every FENError.
+var ErrInvalidFEN = errors.New("invalid FEN")
+
+// FENError describes what is wrong with a FEN string and where.
+type FENError struct {
+	// Field is the name of the FEN field, e.g. "piece placement".
+	Field string
+	// Column is the 1-based position in the FEN string where the problem starts.
+	Column int
+	Reason string
+}
+
+func (e *FENError) Error() string {
+	return fmt.Sprintf("%v: %s at column %d: %s", ErrInvalidFEN, e.Field, e.Column, e.Reason)
+}
+
+// Is makes errors.Is(err, ErrInvalidFEN) match.
+func (e *FENError) Is(target error) bool {
+	return target == ErrInvalidFEN
+}
+
+var fenFields = []string{"piece placement", "side to move", "castling", "en passant", "halfmove clock", "fullmove number"}
+
+// ParseFEN parses a position in Forsyth–Edwards Notation. The two move
+// clocks may be left out, as in EPD, and default to 0 and 1.
+func ParseFEN(fen string) (*Position, error) {
+	fields := strings.Fields(fen)
+	columns := make([]int, len(fields))
+	offset := 0
+	for i, f := range fields {
+		offset += strings.Index(fen[offset:], f)
+		columns[i] = offset + 1
+		offset += len(f)
+	}
+	if len(fields) < 4 || len(fields) > 6 {
+		column := len(fen) + 1
+		if len(fields) > 6 {
+			column = columns[6]
+		}
+		return nil, &FENError{Field: "record", Column: column,
+			Reason: fmt.Sprintf("found %d fields, want 4 to 6", len(fields))}
+	}
+	fail := func(field, extra int, format string, args ...any) error {
+		return &FENError{Field: fenFields[field], Column: columns[field] + extra, Reason: fmt.Sprintf(format, args...)}
+	}
+
+	p := NewPosition()
+	rank, file := 7, 0
+	for i := 0; i < len(fields[0]); i++ {
+		c := fields[0][i]
+		switch {
+		case c == '/':
+			if file != 8 {
+				return nil, fail(0, i, "rank %d has %d squares, want 8", rank+1, file)
+			}
+			if rank == 0 {
+				return nil, fail(0, i, "more than 8 ranks")
+			}
+			rank, file = rank-1, 0
+		case c >= '1' && c <= '8':
+			file += int(c - '0')
+			if file > 8 {
+				return nil, fail(0, i, "rank %d has more than 8 squares", rank+1)
+			}
+		default:
+			piece, ok := pieceFromLetter(c)
+			if !ok {
+				return nil, fail(0, i, "unknown piece %q", c)
+			}
+			if file >= 8 {
+				return nil, fail(0, i, "rank %d has more than 8 squares", rank+1)
+			}
+			p.Put(NewSquare(file, rank), piece)
+			file++
+		}
+	}
+	if rank != 0 || file != 8 {
+		return nil, fail(0, len(fields[0]), "board ends at rank %d after %d squares, want 8 ranks of 8", rank+1, file)
+	}
+
+	switch fields[1] {
+	case "w":
+		p.SideToMove = White
+	case "b":
+		p.SideToMove = Black
+	default:
+		return nil, fail(1, 0, "got %q, want \"w\" or \"b\"", fields[1])
+	}
+
+	if fields[2] != "-" {
+		for i := 0; i < len(fields[2]); i++ {
+			bit := strings.IndexByte("KQkq", fields[2][i])
+			if bit < 0 {
+				return nil, fail(2, i, "unknown castling right %q", fields[2][i])
+			}
+			if p.Castling&(1<<bit) != 0 {
+				return nil, fail(2, i, "castling right %q given twice", fields[2][i])
+			}
+			p.Castling |= 1 << bit
+		}
+	}
+
+	if fields[3] != "-" {
+		ep, err := ParseSquare(fields[3])
+		if err != nil {
+			return nil, fail(3, 0, "%v", err)
+		}
+		// The pawn that skipped the square belongs to the side that just moved.
+		want := 5
+		if p.SideToMove == Black {
+			want = 2
+		}
+		if ep.Rank() != want {
+			return nil, fail(3, 0, "%v is not on rank %d", ep, want+1)
+		}
+		p.EnPassant = ep
+	}
+
+	if len(fields) > 4 {
+		n, err := strconv.Atoi(fields[4])
+		if err != nil || n < 0 {
+			return nil, fail(4, 0, "got %q, want a non-negative number", fields[4])
+		}
+		p.HalfmoveClock = n
+	}
+	if len(fields) > 5 {
+		n, err := strconv.Atoi(fields[5])
+		if err != nil || n < 1 {
+			return nil, fail(5, 0, "got %q, want a positive number", fields[5])
+		}
+		p.FullmoveNumber = n
+	}
+	return p, nil
+}
+
+// FEN serializes the position in Forsyth–Edwards Notation.
+func (p *Position) FEN() string {
+	var b strings.Builder
+	for rank := 7; rank >= 0; rank-- {
+		empty := 0
+		for file := 0; file < 8; file++ {
+			piece := p.squares[NewSquare(file, rank)]
+			if piece == NoPiece {
+				empty++
+				continue
+			}
+			if empty > 0 {
+				b.WriteByte(byte('0' + empty))
+				empty = 0
+			}
+			b.WriteByte(piece.Letter())
+		}
+		if empty > 0 {
+			b.WriteByte(byte('0' + empty))
+		}
+		if rank > 0 {
+			b.WriteByte('/')
+		}
+	}
+	side := "w"
+	if p.SideToMove == Black {
+		side = "b"
+	}
+	fmt.Fprintf(&b, " %s %v %v %d %d", side, p.Castling, p.EnPassant, p.HalfmoveClock, p.FullmoveNumber)
+	return b.String()
+}
+
+func (p *Position) String() string {
+	return p.FEN()
+}
@@ -0,0 +1,128 @@
+package chessboard
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestFENRoundTrip(t *testing.T) {
+	testCases := []string{
+		StartFEN,
+		"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+		"rnbqkbnr/pp1ppppp/8/2p5/4P3/8/PPPP1PPP/RNBQKBNR w KQkq c6 0 2",
+		"8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1",
+		"4k3/8/8/8/8/8/8/4K3 b - - 37 80",
+	}
+	for _, fen := range testCases {
+		t.Run(fen, func(t *testing.T) {
+			p, err := ParseFEN(fen)
+			if err != nil {
+				t.Fatalf("ParseFEN(%q) error = %v", fen, err)
+			}
+			if got := p.FEN(); got != fen {
+				t.Errorf("ParseFEN(%q).FEN() = %q", fen, got)
+			}
+		})
+	}
+}
+
+func TestParseFENFields(t *testing.T) {
+	p, err := ParseFEN("rnbqkbnr/pp1ppppp/8/2p5/4P3/8/PPPP1PPP/RNBQKBNR w Kq c6 1 2")
+	if err != nil {
+		t.Fatalf("ParseFEN() error = %v", err)
+	}
+	e1, _ := ParseSquare("e1")
+	if got := p.Piece(e1); got != (Piece{Kind: King, Color: White}) {
+		t.Errorf("Piece(e1) = %v, want K", got)
+	}
+	c5, _ := ParseSquare("c5")
+	if got := p.Piece(c5); got != (Piece{Kind: Pawn, Color: Black}) {
+		t.Errorf("Piece(c5) = %v, want p", got)
+	}
+	if p.SideToMove != White || p.Castling != WhiteKingside|BlackQueenside ||
+		p.EnPassant.String() != "c6" || p.HalfmoveClock != 1 || p.FullmoveNumber != 2 {
+		t.Errorf("ParseFEN() = %+v", p)
+	}
+
+	epd, err := ParseFEN("8/8/8/8/8/8/8/K6k b - -")
+	if err != nil {
+		t.Fatalf("ParseFEN() without clocks error = %v", err)
+	}
+	if epd.HalfmoveClock != 0 || epd.FullmoveNumber != 1 {
+		t.Errorf("ParseFEN() without clocks = %d %d, want 0 1", epd.HalfmoveClock, epd.FullmoveNumber)
+	}
+}
+
+func TestParseFENErrors(t *testing.T) {
+	testCases := []struct {
+		fen    string
+		field  string
+		column int
+	}{
+		{fen: "8/8/8/8/8/8/8/8 w -", field: "record", column: 20},
+		{fen: "8/8/8/8/8/8/8/8 w - - 0 1 extra", field: "record", column: 27},
+		{fen: "8/8/8/8/8/8/8/7 w - - 0 1", field: "piece placement", column: 16},
+		{fen: "8/8/8/8/8/8/8/9 w - - 0 1", field: "piece placement", column: 15},
+		{fen: "8/8/7/8/8/8/8/8 w - - 0 1", field: "piece placement", column: 6},
+		{fen: "8/8/8/8/8/8/8/8/8 w - - 0 1", field: "piece placement", column: 16},
+		{fen: "8/8/8/8/8/8/8/7x w - - 0 1", field: "piece placement", column: 16},
+		{fen: "8/8/8/8/8/8/8/8 white - - 0 1", field: "side to move", column: 17},
+		{fen: "8/8/8/8/8/8/8/8 w KQxq - 0 1", field: "castling", column: 21},
+		{fen: "8/8/8/8/8/8/8/8 w KK - 0 1", field: "castling", column: 20},
+		{fen: "8/8/8/8/8/8/8/8 w - e3 0 1", field: "en passant", column: 21},
+		{fen: "8/8/8/8/8/8/8/8 w - z9 0 1", field: "en passant", column: 21},
+		{fen: "8/8/8/8/8/8/8/8 w - - -1 1", field: "halfmove clock", column: 23},
+		{fen: "8/8/8/8/8/8/8/8 w - - 0 0", field: "fullmove number", column: 25},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.fen, func(t *testing.T) {
+			_, err := ParseFEN(tc.fen)
+			var fenErr *FENError
+			if !errors.As(err, &fenErr) || !errors.Is(err, ErrInvalidFEN) {
+				t.Fatalf("ParseFEN(%q) error = %v, want a *FENError", tc.fen, err)
+			}
+			if fenErr.Field != tc.field || fenErr.Column != tc.column {
+				t.Errorf("ParseFEN(%q) error at %s column %d, want %s column %d: %v",
+					tc.fen, fenErr.Field, fenErr.Column, tc.field, tc.column, err)
+			}
+		})
+	}
+}
+
+func TestPositionCounts(t *testing.T) {
+	cb := newChessboard()
+	p := PositionFromChessboard(cb, Piece{Kind: Pawn, Color: White})
+	for _, file := range []string{"A", "B", "C", "D", "E", "F", "G", "H", "Z"} {
+		t.Run(fmt.Sprintf("file %s", file), func(t *testing.T) {
+			if got, want := p.CountInFile(file), CountInFile(cb, file); got != want {
+				t.Errorf("CountInFile(%q) = %d, want %d", file, got, want)
+			}
+		})
+	}
+	for rank := -1; rank <= 9; rank++ {
+		t.Run(fmt.Sprintf("rank %d", rank), func(t *testing.T) {
+			if got, want := p.CountInRank(rank), CountInRank(cb, rank); got != want {
+				t.Errorf("CountInRank(%d) = %d, want %d", rank, got, want)
+			}
+		})
+	}
+	if got, want := p.CountOccupied(), CountOccupied(cb); got != want {
+		t.Errorf("CountOccupied() = %d, want %d", got, want)
+	}
+
+	back := p.Chessboard()
+	for file, squares := range cb {
+		for rank, occupied := range squares {
+			if back[file][rank] != occupied {
+				t.Errorf("Chessboard()[%s][%d] = %v, want %v", file, rank, back[file][rank], occupied)
+			}
+		}
+	}
+
+	start, _ := ParseFEN(StartFEN)
+	if start.CountOccupied() != 32 || start.CountInRank(2) != 8 || start.CountInFile("E") != 4 {
+		t.Errorf("start position counts = %d %d %d, want 32 8 4",
+			start.CountOccupied(), start.CountInRank(2), start.CountInFile("E"))
+	}
+}
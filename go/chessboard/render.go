@@ -0,0 +1,249 @@
+package chessboard
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Board is what the renderers draw: both an occupancy map and a
+// position are boards.
+type Board interface {
+	// Occupant returns what stands on the square and whether it is
+	// occupied. A board that only knows occupancy returns NoPiece for
+	// occupied squares.
+	Occupant(s Square) (Piece, bool)
+}
+
+// Occupant implements Board.
+func (cb Chessboard) Occupant(s Square) (Piece, bool) {
+	squares := cb[string(rune('A'+s.File()))]
+	return NoPiece, s.Rank() < len(squares) && squares[s.Rank()]
+}
+
+// Occupant implements Board.
+func (p *Position) Occupant(s Square) (Piece, bool) {
+	return p.squares[s], p.squares[s] != NoPiece
+}
+
+// Arrow points from one square to another, e.g. to show a move.
+type Arrow struct {
+	From, To Square
+}
+
+// RenderOptions change how a board is drawn. The zero value draws the
+// board from White's side without any decoration.
+type RenderOptions struct {
+	// Flip draws the board from Black's side.
+	Flip bool
+	// Color adds ANSI colours to Unicode output.
+	Color bool
+	// Highlights are squares drawn in a highlight colour, in Unicode
+	// output with Color and in SVG output. ASCII output ignores them.
+	Highlights []Square
+	// Arrows are drawn in SVG output only.
+	Arrows []Arrow
+	// SquareSize is the size of a square in SVG output, 45 if zero.
+	SquareSize int
+}
+
+// files and ranks return the order in which files and ranks are drawn,
+// left to right and top to bottom.
+func (o RenderOptions) files() []int {
+	files := []int{0, 1, 2, 3, 4, 5, 6, 7}
+	if o.Flip {
+		slices.Reverse(files)
+	}
+	return files
+}
+
+func (o RenderOptions) ranks() []int {
+	ranks := []int{7, 6, 5, 4, 3, 2, 1, 0}
+	if o.Flip {
+		slices.Reverse(ranks)
+	}
+	return ranks
+}
+
+// fileLabels returns the file letters in drawing order, in the given case.
+func (o RenderOptions) fileLabels(base byte) []string {
+	var labels []string
+	for _, f := range o.files() {
+		labels = append(labels, string(rune(base+byte(f))))
+	}
+	return labels
+}
+
+// RenderASCII draws the board with FEN letters for pieces, "#" for
+// occupied squares of unknown content and "_" for empty squares, with
+// file letters above and below and rank numbers on both sides.
+func RenderASCII(b Board, opts RenderOptions) string {
+	var sb strings.Builder
+	header := "  " + strings.Join(opts.fileLabels('A'), " ") + "\n"
+	sb.WriteString(header)
+	for _, rank := range opts.ranks() {
+		fmt.Fprintf(&sb, "%d", rank+1)
+		for _, file := range opts.files() {
+			c := byte('_')
+			if piece, ok := b.Occupant(NewSquare(file, rank)); ok {
+				c = '#'
+				if piece != NoPiece {
+					c = piece.Letter()
+				}
+			}
+			sb.WriteByte(' ')
+			sb.WriteByte(c)
+		}
+		fmt.Fprintf(&sb, " %d\n", rank+1)
+	}
+	sb.WriteString(header)
+	return sb.String()
+}
+
+var (
+	pieceGlyphs = [2][7]string{
+		White: {Pawn: "♙", Knight: "♘", Bishop: "♗", Rook: "♖", Queen: "♕", King: "♔"},
+		Black: {Pawn: "♟", Knight: "♞", Bishop: "♝", Rook: "♜", Queen: "♛", King: "♚"},
+	}
+	unknownGlyph = "●"
+	emptyGlyph   = "·"
+)
+
+// ANSI escape sequences used by RenderUnicode.
+const (
+	ansiReset       = "\x1b[0m"
+	ansiLight       = "\x1b[48;5;180m"
+	ansiDark        = "\x1b[48;5;137m"
+	ansiHighlight   = "\x1b[48;5;185m"
+	ansiWhitePiece  = "\x1b[97m"
+	ansiBlackPiece  = "\x1b[30m"
+	ansiUnknownMark = "\x1b[90m"
+)
+
+// RenderUnicode draws the board with Unicode chess glyphs. With
+// opts.Color, squares and pieces are coloured with ANSI escapes and the
+// highlighted squares stand out; without it, empty squares are dots.
+func RenderUnicode(b Board, opts RenderOptions) string {
+	var sb strings.Builder
+	header := "  " + strings.Join(opts.fileLabels('a'), " ") + "\n"
+	sb.WriteString(header)
+	for _, rank := range opts.ranks() {
+		fmt.Fprintf(&sb, "%d", rank+1)
+		if opts.Color {
+			sb.WriteByte(' ')
+		}
+		for _, file := range opts.files() {
+			s := NewSquare(file, rank)
+			piece, ok := b.Occupant(s)
+			glyph := emptyGlyph
+			switch {
+			case ok && piece == NoPiece:
+				glyph = unknownGlyph
+			case ok:
+				glyph = pieceGlyphs[piece.Color][piece.Kind]
+			}
+			if !opts.Color {
+				sb.WriteString(" " + glyph)
+				continue
+			}
+			background := ansiDark
+			if (file+rank)%2 == 1 {
+				background = ansiLight
+			}
+			if slices.Contains(opts.Highlights, s) {
+				background = ansiHighlight
+			}
+			foreground := ansiUnknownMark
+			if ok && piece != NoPiece {
+				// Filled glyphs for both sides read better on coloured squares.
+				glyph = pieceGlyphs[Black][piece.Kind]
+				foreground = ansiBlackPiece
+				if piece.Color == White {
+					foreground = ansiWhitePiece
+				}
+			}
+			if !ok {
+				glyph = " "
+			}
+			sb.WriteString(background + foreground + glyph + " " + ansiReset)
+		}
+		fmt.Fprintf(&sb, " %d\n", rank+1)
+	}
+	sb.WriteString(header)
+	return sb.String()
+}
+
+const (
+	svgLight     = "#f0d9b5"
+	svgDark      = "#b58863"
+	svgHighlight = "#f7ec5d"
+	svgArrow     = "#15781b"
+)
+
+// RenderSVG draws the board as a standalone SVG document with file and
+// rank labels around it, highlighted squares and arrows. Pieces are drawn
+// as Unicode glyphs, so no external images are needed.
+func RenderSVG(b Board, opts RenderOptions) string {
+	size := opts.SquareSize
+	if size <= 0 {
+		size = 45
+	}
+	margin := size / 2
+	total := 8*size + 2*margin
+	// x and y return the top-left corner of a square as drawn.
+	x := func(s Square) int { return margin + slices.Index(opts.files(), s.File())*size }
+	y := func(s Square) int { return margin + slices.Index(opts.ranks(), s.Rank())*size }
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n",
+		total, total, total, total)
+	if len(opts.Arrows) > 0 {
+		sb.WriteString(`<defs><marker id="arrowhead" viewBox="0 0 10 10" refX="5" refY="5" markerWidth="3" markerHeight="3" orient="auto">`)
+		fmt.Fprintf(&sb, `<path d="M0,0 L10,5 L0,10 z" fill="%s"/></marker></defs>`+"\n", svgArrow)
+	}
+	fmt.Fprintf(&sb, `<rect width="%d" height="%d" fill="#ffffff"/>`+"\n", total, total)
+
+	for s := Square(0); s < 64; s++ {
+		fill := svgDark
+		if (s.File()+s.Rank())%2 == 1 {
+			fill = svgLight
+		}
+		if slices.Contains(opts.Highlights, s) {
+			fill = svgHighlight
+		}
+		fmt.Fprintf(&sb, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"/>`+"\n", x(s), y(s), size, size, fill)
+	}
+
+	fontSize := size / 3
+	for i, label := range opts.fileLabels('a') {
+		cx := margin + i*size + size/2
+		fmt.Fprintf(&sb, `<text x="%d" y="%d" font-size="%d" text-anchor="middle" dominant-baseline="central">%s</text>`+"\n",
+			cx, total-margin/2, fontSize, label)
+	}
+	for i, rank := range opts.ranks() {
+		cy := margin + i*size + size/2
+		fmt.Fprintf(&sb, `<text x="%d" y="%d" font-size="%d" text-anchor="middle" dominant-baseline="central">%d</text>`+"\n",
+			margin/2, cy, fontSize, rank+1)
+	}
+
+	for s := Square(0); s < 64; s++ {
+		piece, ok := b.Occupant(s)
+		if !ok {
+			continue
+		}
+		cx, cy := x(s)+size/2, y(s)+size/2
+		if piece == NoPiece {
+			fmt.Fprintf(&sb, `<circle cx="%d" cy="%d" r="%d" fill="#444444"/>`+"\n", cx, cy, size/4)
+			continue
+		}
+		fmt.Fprintf(&sb, `<text x="%d" y="%d" font-size="%d" text-anchor="middle" dominant-baseline="central">%s</text>`+"\n",
+			cx, cy, size*4/5, pieceGlyphs[piece.Color][piece.Kind])
+	}
+
+	for _, a := range opts.Arrows {
+		fmt.Fprintf(&sb, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="%s" stroke-width="%d" stroke-opacity="0.8" marker-end="url(#arrowhead)"/>`+"\n",
+			x(a.From)+size/2, y(a.From)+size/2, x(a.To)+size/2, y(a.To)+size/2, svgArrow, max(size/6, 1))
+	}
+	sb.WriteString("</svg>\n")
+	return sb.String()
+}
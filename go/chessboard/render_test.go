@@ -0,0 +1,96 @@
+package chessboard
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+func checkGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading golden file: %v (run go test -update to create it)", err)
+	}
+	if got != string(want) {
+		t.Errorf("output differs from %s:\n%s\nwant:\n%s", path, got, want)
+	}
+}
+
+func TestRender(t *testing.T) {
+	start, _ := ParseFEN(StartFEN)
+	kiwipete, _ := ParseFEN(perftPositions[1].fen)
+	e2, _ := ParseSquare("e2")
+	e4, _ := ParseSquare("e4")
+	g1, _ := ParseSquare("g1")
+	f3, _ := ParseSquare("f3")
+	move := RenderOptions{Highlights: []Square{e2, e4}, Arrows: []Arrow{{From: g1, To: f3}}}
+
+	testCases := []struct {
+		name   string
+		render func(Board, RenderOptions) string
+		board  Board
+		opts   RenderOptions
+	}{
+		{name: "chessboard.ascii", render: RenderASCII, board: newChessboard()},
+		{name: "start.ascii", render: RenderASCII, board: start},
+		{name: "kiwipete-flipped.ascii", render: RenderASCII, board: kiwipete, opts: RenderOptions{Flip: true}},
+		{name: "chessboard.unicode", render: RenderUnicode, board: newChessboard()},
+		{name: "start.unicode", render: RenderUnicode, board: start},
+		{name: "start-color.unicode", render: RenderUnicode, board: start, opts: RenderOptions{Highlights: move.Highlights, Arrows: move.Arrows, Color: true}},
+		{name: "chessboard.svg", render: RenderSVG, board: newChessboard()},
+		{name: "start-move.svg", render: RenderSVG, board: start, opts: move},
+		{name: "kiwipete-flipped.svg", render: RenderSVG, board: kiwipete, opts: RenderOptions{Flip: true, SquareSize: 60}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			checkGolden(t, tc.name, tc.render(tc.board, tc.opts))
+		})
+	}
+}
+
+func TestRenderASCIIMatchesFixture(t *testing.T) {
+	// The drawing in the comment on newChessboard.
+	want := `  A B C D E F G H
+8 # _ _ _ # _ _ # 8
+7 _ _ _ _ _ _ _ _ 7
+6 _ _ _ _ # _ _ # 6
+5 _ # _ _ _ _ _ # 5
+4 _ _ _ _ _ _ # # 4
+3 # _ # _ _ _ _ # 3
+2 _ _ _ _ _ _ _ # 2
+1 # _ _ _ _ _ _ # 1
+  A B C D E F G H
+`
+	if got := RenderASCII(newChessboard(), RenderOptions{}); got != want {
+		t.Errorf("RenderASCII() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRenderBoardsAgree(t *testing.T) {
+	cb := newChessboard()
+	p := PositionFromChessboard(cb, NoPiece)
+	for s := range BitboardFromChessboard(cb).Squares() {
+		p.Put(s, Piece{Kind: Pawn, Color: White})
+	}
+	ascii := strings.ReplaceAll(RenderASCII(p, RenderOptions{}), "P", "#")
+	if ascii != RenderASCII(cb, RenderOptions{}) {
+		t.Errorf("RenderASCII() of a position and of its occupancy map differ:\n%s", ascii)
+	}
+
+	svg := RenderSVG(cb, RenderOptions{})
+	if !strings.HasPrefix(svg, "<svg xmlns=\"http://www.w3.org/2000/svg\"") || strings.Count(svg, "<circle") != CountOccupied(cb) {
+		t.Errorf("RenderSVG() draws %d occupied squares, want %d", strings.Count(svg, "<circle"), CountOccupied(cb))
+	}
+}
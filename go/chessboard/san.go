@@ -0,0 +1,158 @@
+package chessboard
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrInvalidSAN is returned for move text that is not valid SAN.
+	ErrInvalidSAN = errors.New("invalid SAN")
+	// ErrIllegalMove is returned when a move is not legal in the position.
+	ErrIllegalMove = errors.New("illegal move")
+	// ErrAmbiguousMove is returned when SAN matches more than one legal move.
+	ErrAmbiguousMove = errors.New("ambiguous move")
+)
+
+// SAN returns the move in Standard Algebraic Notation, e.g. "Nbd7",
+// "exd6", "e8=Q+" or "O-O-O#". The move must be legal in the position.
+func (p *Position) SAN(m Move) string {
+	piece := p.squares[m.From]
+	var b strings.Builder
+	switch {
+	case piece.Kind == King && m.To.File()-m.From.File() == 2:
+		b.WriteString("O-O")
+	case piece.Kind == King && m.From.File()-m.To.File() == 2:
+		b.WriteString("O-O-O")
+	default:
+		capture := p.squares[m.To] != NoPiece
+		if piece.Kind == Pawn {
+			capture = m.From.File() != m.To.File()
+			if capture {
+				b.WriteByte(byte('a' + m.From.File()))
+			}
+		} else {
+			b.WriteByte(pieceLetters[piece.Kind] - ('a' - 'A'))
+			b.WriteString(p.disambiguation(m))
+		}
+		if capture {
+			b.WriteByte('x')
+		}
+		b.WriteString(m.To.String())
+		if m.Promotion != NoKind {
+			b.WriteByte('=')
+			b.WriteByte(pieceLetters[m.Promotion] - ('a' - 'A'))
+		}
+	}
+
+	u := p.MakeMove(m)
+	if p.InCheck() {
+		if len(p.LegalMoves()) == 0 {
+			b.WriteByte('#')
+		} else {
+			b.WriteByte('+')
+		}
+	}
+	p.UnmakeMove(u)
+	return b.String()
+}
+
+// disambiguation returns the file, rank or square of m.From needed to tell
+// m apart from other legal moves of the same kind of piece to m.To.
+func (p *Position) disambiguation(m Move) string {
+	kind := p.squares[m.From].Kind
+	ambiguous, sameFile, sameRank := false, false, false
+	for _, other := range p.LegalMoves() {
+		if other.To != m.To || other.From == m.From || p.squares[other.From].Kind != kind {
+			continue
+		}
+		ambiguous = true
+		sameFile = sameFile || other.From.File() == m.From.File()
+		sameRank = sameRank || other.From.Rank() == m.From.Rank()
+	}
+	switch {
+	case !ambiguous:
+		return ""
+	case !sameFile:
+		return m.From.String()[:1]
+	case !sameRank:
+		return m.From.String()[1:]
+	default:
+		return m.From.String()
+	}
+}
+
+// ParseSAN finds the legal move written in Standard Algebraic Notation.
+// Check marks and annotations such as "!?" are ignored, castling may be
+// written with zeros, and the "=" before a promotion may be left out.
+func (p *Position) ParseSAN(san string) (Move, error) {
+	text := strings.TrimRight(san, "+#!?")
+	kind, promotion := Pawn, NoKind
+	fromFile, fromRank := -1, -1
+	var to Square
+
+	switch text {
+	case "O-O", "0-0", "O-O-O", "0-0-0":
+		kind = King
+		file := 6
+		if len(text) == 5 {
+			file = 2
+		}
+		fromFile = 4
+		to = NewSquare(file, 0)
+		if p.SideToMove == Black {
+			to = NewSquare(file, 7)
+		}
+	default:
+		if i := strings.IndexByte(text, '='); i >= 0 {
+			if i != len(text)-2 {
+				return Move{}, fmt.Errorf("%w: %q", ErrInvalidSAN, san)
+			}
+			text = text[:i] + text[i+1:]
+		}
+		if n := len(text); n > 2 && strings.IndexByte("NBRQ", text[n-1]) >= 0 && text[n-2] >= '1' && text[n-2] <= '8' {
+			promotion = Kind(strings.IndexByte(pieceLetters, text[n-1]+('a'-'A')))
+			text = text[:n-1]
+		}
+		if len(text) > 0 && strings.IndexByte("NBRQK", text[0]) >= 0 {
+			kind = Kind(strings.IndexByte(pieceLetters, text[0]+('a'-'A')))
+			text = text[1:]
+		}
+		if len(text) < 2 {
+			return Move{}, fmt.Errorf("%w: %q", ErrInvalidSAN, san)
+		}
+		var err error
+		to, err = ParseSquare(text[len(text)-2:])
+		if err != nil || text[len(text)-2] < 'a' {
+			return Move{}, fmt.Errorf("%w: %q", ErrInvalidSAN, san)
+		}
+		prefix := strings.TrimSuffix(text[:len(text)-2], "x")
+		for i := 0; i < len(prefix); i++ {
+			switch c := prefix[i]; {
+			case c >= 'a' && c <= 'h' && fromFile < 0 && fromRank < 0:
+				fromFile = int(c - 'a')
+			case c >= '1' && c <= '8' && fromRank < 0:
+				fromRank = int(c - '1')
+			default:
+				return Move{}, fmt.Errorf("%w: %q", ErrInvalidSAN, san)
+			}
+		}
+	}
+
+	var found []Move
+	for _, m := range p.LegalMoves() {
+		if m.To == to && m.Promotion == promotion && p.squares[m.From].Kind == kind &&
+			(fromFile < 0 || m.From.File() == fromFile) && (fromRank < 0 || m.From.Rank() == fromRank) {
+			found = append(found, m)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return Move{}, fmt.Errorf("%w: %s in %s", ErrIllegalMove, san, p.FEN())
+	case 1:
+		return found[0], nil
+	default:
+		return Move{}, fmt.Errorf("%w: %s matches %v", ErrAmbiguousMove, san, found)
+	}
+}
@@ -0,0 +1,445 @@
+package chessboard
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+const (
+	// MateScore is the score of giving mate right now; a mate n plies
+	// away scores MateScore - n.
+	MateScore = 30000
+	infinity  = MateScore + 1
+	maxPly    = 64
+)
+
+// SearchLimits says when a search has to stop. Zero fields are no limit;
+// with no limit at all, the search runs until its context is cancelled
+// or it reaches the maximum depth.
+type SearchLimits struct {
+	Depth    int
+	Nodes    int64
+	MoveTime time.Duration
+	// Time and Increment are the clock of each color, indexed by Color.
+	// When the side to move has time left, the search budgets part of it.
+	Time      [2]time.Duration
+	Increment [2]time.Duration
+	// MovesToGo is the number of moves until the next time control, or
+	// zero for the rest of the game.
+	MovesToGo int
+}
+
+// SearchInfo reports the result of a search iteration.
+type SearchInfo struct {
+	Depth int
+	// Score is in centipawns from the point of view of the side to move.
+	Score int
+	// Mate is the number of moves to mate, negative when the side to move
+	// is getting mated, and zero when no mate was found.
+	Mate  int
+	Nodes int64
+	Time  time.Duration
+	// PV is the principal variation; its first move is the best move.
+	PV []Move
+}
+
+// Engine searches positions for the best move. It keeps a transposition
+// table between searches, so it must not run two searches at once.
+type Engine struct {
+	// Info, if set, is called after every completed iteration.
+	Info func(SearchInfo)
+	tt   []ttEntry
+}
+
+type ttFlag uint8
+
+const (
+	ttExact ttFlag = iota + 1
+	ttLower
+	ttUpper
+)
+
+type ttEntry struct {
+	key   uint64
+	move  Move
+	score int32
+	depth int8
+	flag  ttFlag
+}
+
+// NewEngine returns an engine with a transposition table of about the
+// given size in megabytes.
+func NewEngine(hashMB int) *Engine {
+	e := &Engine{}
+	e.Resize(hashMB)
+	return e
+}
+
+// Resize replaces the transposition table with an empty one of about the
+// given size in megabytes, rounded down to a power of two entries.
+func (e *Engine) Resize(hashMB int) {
+	n := 1
+	for n*2*24 <= max(hashMB, 1)<<20 {
+		n *= 2
+	}
+	e.tt = make([]ttEntry, n)
+}
+
+// Clear forgets everything learnt from earlier searches.
+func (e *Engine) Clear() {
+	clear(e.tt)
+}
+
+// Search looks for the best move in p using iterative deepening. history
+// holds the hashes of the positions before p in the game, so repetitions
+// are scored as draws. Search stops at the limits or when ctx is done,
+// and returns the last completed iteration. PV is empty when there is no
+// legal move.
+func (e *Engine) Search(ctx context.Context, p *Position, history []uint64, limits SearchLimits) SearchInfo {
+	s := &searcher{
+		e:       e,
+		p:       *p,
+		ctx:     ctx,
+		start:   time.Now(),
+		limits:  limits,
+		history: append(slices.Clone(history), p.Hash()),
+	}
+	soft := s.budget()
+
+	legal := s.p.LegalMoves()
+	result := SearchInfo{}
+	if len(legal) > 0 {
+		result.PV = []Move{legal[0]}
+	}
+	maxDepth := maxPly - 1
+	if limits.Depth > 0 {
+		maxDepth = min(limits.Depth, maxDepth)
+	}
+	for depth := 1; depth <= maxDepth && len(legal) > 0; depth++ {
+		score := s.negamax(depth, 0, -infinity, infinity)
+		if s.stopped {
+			break
+		}
+		result = SearchInfo{Depth: depth, Score: score, Nodes: s.nodes, Time: time.Since(s.start), PV: s.pv(s.rootBest, depth)}
+		if score > MateScore-maxPly {
+			result.Mate = (MateScore - score + 1) / 2
+		} else if score < -MateScore+maxPly {
+			result.Mate = -(MateScore + score) / 2
+		}
+		if e.Info != nil {
+			e.Info(result)
+		}
+		if result.Mate != 0 || (soft > 0 && time.Since(s.start) > soft) {
+			break
+		}
+	}
+	return result
+}
+
+type searcher struct {
+	e        *Engine
+	p        Position
+	ctx      context.Context
+	start    time.Time
+	limits   SearchLimits
+	deadline time.Time
+	nodes    int64
+	stopped  bool
+	rootBest Move
+	history  []uint64
+	killers  [maxPly][2]Move
+	buffers  [maxPly + 1][]Move
+}
+
+// budget sets the hard deadline and returns the soft limit after which
+// no new iteration is started, or zero for none.
+func (s *searcher) budget() time.Duration {
+	l := s.limits
+	if l.MoveTime > 0 {
+		s.deadline = s.start.Add(l.MoveTime)
+		return 0
+	}
+	left := l.Time[s.p.SideToMove]
+	if left <= 0 {
+		return 0
+	}
+	moves := l.MovesToGo
+	if moves <= 0 {
+		moves = 30
+	}
+	// Keep a safety margin so the engine never loses on time.
+	alloc := left/time.Duration(moves) + l.Increment[s.p.SideToMove]*3/4
+	alloc = max(min(alloc, left-50*time.Millisecond), time.Millisecond)
+	s.deadline = s.start.Add(alloc)
+	return alloc / 2
+}
+
+// checkStop polls the stop conditions every 1024 nodes.
+func (s *searcher) checkStop() bool {
+	if s.stopped {
+		return true
+	}
+	if s.nodes&1023 != 0 {
+		return false
+	}
+	if s.ctx.Err() != nil ||
+		(s.limits.Nodes > 0 && s.nodes >= s.limits.Nodes) ||
+		(!s.deadline.IsZero() && time.Now().After(s.deadline)) {
+		s.stopped = true
+	}
+	return s.stopped
+}
+
+// repeated reports whether the current position occurred before since the
+// last capture or pawn move.
+func (s *searcher) repeated() bool {
+	n := len(s.history) - 1
+	for i := n - 2; i >= 0 && i >= n-s.p.HalfmoveClock; i -= 2 {
+		if s.history[i] == s.history[n] {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *searcher) makeMove(m Move) Undo {
+	u := s.p.MakeMove(m)
+	s.history = append(s.history, s.p.Hash())
+	return u
+}
+
+func (s *searcher) unmakeMove(u Undo) {
+	s.history = s.history[:len(s.history)-1]
+	s.p.UnmakeMove(u)
+}
+
+func (s *searcher) negamax(depth, ply, alpha, beta int) int {
+	if s.checkStop() {
+		return 0
+	}
+	if ply > 0 && (s.p.HalfmoveClock >= 100 || s.repeated()) {
+		return 0
+	}
+	inCheck := s.p.InCheck()
+	if inCheck {
+		depth++
+	}
+	if depth <= 0 || ply >= maxPly {
+		return s.quiesce(ply, alpha, beta)
+	}
+	s.nodes++
+
+	hash := s.p.Hash()
+	entry := &s.e.tt[hash&uint64(len(s.e.tt)-1)]
+	var ttMove Move
+	if entry.key == hash {
+		ttMove = entry.move
+		if ply > 0 && int(entry.depth) >= depth {
+			score := fromTT(int(entry.score), ply)
+			switch {
+			case entry.flag == ttExact,
+				entry.flag == ttLower && score >= beta,
+				entry.flag == ttUpper && score <= alpha:
+				return score
+			}
+		}
+	}
+
+	moves := s.p.appendLegalMoves(s.buffers[ply][:0])
+	s.buffers[ply] = moves
+	if len(moves) == 0 {
+		if inCheck {
+			return -MateScore + ply
+		}
+		return 0
+	}
+	s.order(moves, ply, ttMove)
+
+	best, bestMove, flag := -infinity, moves[0], ttUpper
+	for _, m := range moves {
+		quiet := s.p.squares[m.To] == NoPiece && m.Promotion == NoKind
+		u := s.makeMove(m)
+		score := -s.negamax(depth-1, ply+1, -beta, -alpha)
+		s.unmakeMove(u)
+		if s.stopped {
+			return 0
+		}
+		if score > best {
+			best, bestMove = score, m
+			if ply == 0 {
+				s.rootBest = m
+			}
+		}
+		if score > alpha {
+			alpha, flag = score, ttExact
+		}
+		if alpha >= beta {
+			flag = ttLower
+			if quiet && s.killers[ply][0] != m {
+				s.killers[ply][1], s.killers[ply][0] = s.killers[ply][0], m
+			}
+			break
+		}
+	}
+	*entry = ttEntry{key: hash, move: bestMove, score: int32(toTT(best, ply)), depth: int8(depth), flag: flag}
+	return best
+}
+
+// quiesce searches captures only, so the evaluation is not taken in the
+// middle of an exchange.
+func (s *searcher) quiesce(ply, alpha, beta int) int {
+	if s.checkStop() {
+		return 0
+	}
+	s.nodes++
+	standPat := evaluate(&s.p)
+	if standPat >= beta || ply >= maxPly {
+		return standPat
+	}
+	alpha = max(alpha, standPat)
+
+	moves := s.p.appendLegalMoves(s.buffers[ply][:0])
+	s.buffers[ply] = moves
+	captures := moves[:0]
+	for _, m := range moves {
+		if s.p.squares[m.To] != NoPiece || m.Promotion == Queen {
+			captures = append(captures, m)
+		}
+	}
+	s.order(captures, ply, Move{})
+	for _, m := range captures {
+		u := s.p.MakeMove(m)
+		score := -s.quiesce(ply+1, -beta, -alpha)
+		s.p.UnmakeMove(u)
+		if s.stopped {
+			return 0
+		}
+		if score >= beta {
+			return score
+		}
+		alpha = max(alpha, score)
+	}
+	return alpha
+}
+
+// order sorts moves so the likely best come first: the transposition
+// table move, then captures of valuable pieces by cheap ones, promotions
+// and killer moves.
+func (s *searcher) order(moves []Move, ply int, ttMove Move) {
+	score := func(m Move) int {
+		switch {
+		case m == ttMove:
+			return 1 << 20
+		case s.p.squares[m.To] != NoPiece:
+			return 1<<16 + 16*pieceValues[s.p.squares[m.To].Kind] - pieceValues[s.p.squares[m.From].Kind]/16
+		case m.Promotion != NoKind:
+			return 1<<15 + pieceValues[m.Promotion]
+		case ply < maxPly && m == s.killers[ply][0]:
+			return 1 << 14
+		case ply < maxPly && m == s.killers[ply][1]:
+			return 1<<14 - 1
+		}
+		return 0
+	}
+	slices.SortStableFunc(moves, func(a, b Move) int { return score(b) - score(a) })
+}
+
+// pv follows the transposition table from the position after the best
+// root move.
+func (s *searcher) pv(best Move, depth int) []Move {
+	pv := []Move{best}
+	undos := []Undo{s.p.MakeMove(best)}
+	seen := map[uint64]bool{}
+	for len(pv) < depth {
+		hash := s.p.Hash()
+		entry := s.e.tt[hash&uint64(len(s.e.tt)-1)]
+		if entry.key != hash || seen[hash] || !slices.Contains(s.p.LegalMoves(), entry.move) {
+			break
+		}
+		seen[hash] = true
+		pv = append(pv, entry.move)
+		undos = append(undos, s.p.MakeMove(entry.move))
+	}
+	for i := len(undos) - 1; i >= 0; i-- {
+		s.p.UnmakeMove(undos[i])
+	}
+	return pv
+}
+
+// toTT and fromTT store mate scores relative to the node rather than the
+// root, so they stay right when the position is reached at another ply.
+func toTT(score, ply int) int {
+	switch {
+	case score > MateScore-maxPly:
+		return score + ply
+	case score < -MateScore+maxPly:
+		return score - ply
+	}
+	return score
+}
+
+func fromTT(score, ply int) int {
+	switch {
+	case score > MateScore-maxPly:
+		return score - ply
+	case score < -MateScore+maxPly:
+		return score + ply
+	}
+	return score
+}
+
+var pieceValues = [7]int{NoKind: 0, Pawn: 100, Knight: 320, Bishop: 330, Rook: 500, Queen: 900, King: 0}
+
+// evaluate scores the position in centipawns from the point of view of
+// the side to move: material, plus small bonuses for advanced pawns,
+// central minor pieces, rooks on the seventh rank and a sheltered king.
+func evaluate(p *Position) int {
+	score := 0
+	for kind := Pawn; kind <= King; kind++ {
+		for c := White; c <= Black; c++ {
+			sign := 1
+			if c == Black {
+				sign = -1
+			}
+			for s := range (p.byKind[kind] & p.byColor[c]).Squares() {
+				score += sign * (pieceValues[kind] + squareBonus(kind, c, s))
+			}
+		}
+	}
+	if p.SideToMove == Black {
+		return -score
+	}
+	return score
+}
+
+func squareBonus(kind Kind, c Color, s Square) int {
+	file, rank := s.File(), s.Rank()
+	if c == Black {
+		rank = 7 - rank
+	}
+	// center is 6 on the four central squares and 0 in the corners.
+	center := 6 - (abs(2*file-7)/2 + abs(2*rank-7)/2)
+	switch kind {
+	case Pawn:
+		return 5*(rank-1) + 3*(3-abs(2*file-7)/2)
+	case Knight, Bishop:
+		return 5 * center
+	case Rook:
+		if rank == 6 {
+			return 20
+		}
+	case Queen:
+		return center
+	case King:
+		return -4 * center
+	}
+	return 0
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,125 @@
+package chessboard
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestHash(t *testing.T) {
+	for _, tc := range perftPositions {
+		p, _ := ParseFEN(tc.fen)
+		before := p.Hash()
+		for _, m := range p.LegalMoves() {
+			u := p.MakeMove(m)
+			fresh, err := ParseFEN(p.FEN())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p.Hash() != fresh.Hash() {
+				t.Errorf("%s: hash after %v differs from the hash of %q", tc.name, m, p.FEN())
+			}
+			p.UnmakeMove(u)
+			if p.Hash() != before {
+				t.Errorf("%s: hash not restored after %v", tc.name, m)
+			}
+		}
+	}
+
+	a, _ := ParseFEN("4k3/8/8/8/8/8/8/4K3 w - - 0 1")
+	b, _ := ParseFEN("4k3/8/8/8/8/8/8/4K3 b - - 0 1")
+	if a.Hash() == b.Hash() {
+		t.Errorf("Hash() ignores the side to move")
+	}
+}
+
+func TestSearch(t *testing.T) {
+	testCases := []struct {
+		name string
+		fen  string
+		want string
+		mate int
+	}{
+		{name: "back rank mate", fen: "6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", want: "a1a8", mate: 1},
+		{name: "mate in two", fen: "7k/8/8/8/8/8/R7/1R4K1 w - - 0 1", mate: 2},
+		{name: "hanging queen", fen: "4k3/8/8/3q4/8/8/8/3RK3 w - - 0 1", want: "d1d5"},
+		{name: "mated in one", fen: "k7/8/1K6/8/8/8/8/7R b - - 0 1", want: "a8b8", mate: -1},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, _ := ParseFEN(tc.fen)
+			fen := p.FEN()
+			e := NewEngine(1)
+			var iterations int
+			e.Info = func(SearchInfo) { iterations++ }
+			info := e.Search(context.Background(), p, nil, SearchLimits{Depth: 5})
+			if tc.want != "" && (len(info.PV) == 0 || info.PV[0].String() != tc.want) {
+				t.Errorf("Search() PV = %v, want it to start with %s", info.PV, tc.want)
+			}
+			if info.Mate != tc.mate {
+				t.Errorf("Search() Mate = %d, want %d", info.Mate, tc.mate)
+			}
+			if iterations != info.Depth {
+				t.Errorf("Info called %d times for depth %d", iterations, info.Depth)
+			}
+			if p.FEN() != fen {
+				t.Errorf("Search() changed the position to %q", p.FEN())
+			}
+		})
+	}
+}
+
+func TestSearchNoMoves(t *testing.T) {
+	p, _ := ParseFEN("7k/5Q2/6K1/8/8/8/8/8 b - - 0 1")
+	if info := NewEngine(1).Search(context.Background(), p, nil, SearchLimits{Depth: 3}); len(info.PV) != 0 {
+		t.Errorf("Search() in stalemate PV = %v, want none", info.PV)
+	}
+}
+
+func TestSearchRepetition(t *testing.T) {
+	// White is a queen down, but can repeat the position by moving the king.
+	p, _ := ParseFEN("7k/8/8/8/8/8/q7/4K3 w - - 10 40")
+	history := []uint64{p.Hash()}
+	for _, uci := range []string{"e1d1", "a2b2", "d1e1", "b2a2"} {
+		for _, m := range p.LegalMoves() {
+			if m.String() == uci {
+				p.MakeMove(m)
+				history = append(history, p.Hash())
+				break
+			}
+		}
+	}
+	history = history[:len(history)-1]
+	info := NewEngine(1).Search(context.Background(), p, history, SearchLimits{Depth: 4})
+	if info.PV[0].String() != "e1d1" || info.Score != 0 {
+		t.Errorf("Search() = %v with score %d, want e1d1 repeating for a draw", info.PV, info.Score)
+	}
+}
+
+func TestSearchStops(t *testing.T) {
+	p, _ := ParseFEN(StartFEN)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	info := NewEngine(1).Search(ctx, p, nil, SearchLimits{})
+	if info.Depth != 0 || len(info.PV) != 1 {
+		t.Errorf("Search() with a cancelled context = %+v, want a fallback move", info)
+	}
+
+	start := time.Now()
+	NewEngine(1).Search(context.Background(), p, nil, SearchLimits{MoveTime: 50 * time.Millisecond})
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Search() with a 50ms move time took %v", elapsed)
+	}
+
+	start = time.Now()
+	NewEngine(1).Search(context.Background(), p, nil,
+		SearchLimits{Time: [2]time.Duration{White: 2 * time.Second, Black: time.Hour}, MovesToGo: 20})
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Search() with 2s for 20 moves took %v", elapsed)
+	}
+
+	info = NewEngine(1).Search(context.Background(), p, nil, SearchLimits{Nodes: 2000})
+	if info.Nodes > 4000 {
+		t.Errorf("Search() with a 2000 node limit searched %d nodes", info.Nodes)
+	}
+}
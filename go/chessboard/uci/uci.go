@@ -0,0 +1,295 @@
+// Package uci speaks the Universal Chess Interface protocol, so chess GUIs
+// and test harnesses can drive the chessboard engine over a text stream.
+package uci
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"chessboard"
+)
+
+// DefaultHashMB is the transposition table size a new server starts with.
+const DefaultHashMB = 16
+
+// Server runs one UCI session at a time. Searches run in the background,
+// so "stop" and "isready" are answered while the engine thinks.
+type Server struct {
+	Name, Author string
+
+	engine   *chessboard.Engine
+	position *chessboard.Position
+	// history holds the hashes of the positions before position.
+	history []uint64
+
+	mu     sync.Mutex // guards out
+	out    io.Writer
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewServer returns a server set up with the starting position.
+func NewServer() *Server {
+	p, _ := chessboard.ParseFEN(chessboard.StartFEN)
+	return &Server{
+		Name:     "chessboard",
+		Author:   "the go-exercism authors",
+		engine:   chessboard.NewEngine(DefaultHashMB),
+		position: p,
+	}
+}
+
+// Run reads commands from in and writes replies to out until "quit" or the
+// end of the input, which both stop any running search. Commands that
+// cannot be understood are reported with "info string" and otherwise
+// ignored, as the protocol asks.
+func (s *Server) Run(in io.Reader, out io.Writer) error {
+	s.out = out
+	defer s.stop()
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		switch cmd, args := fields[0], fields[1:]; cmd {
+		case "uci":
+			s.send("id name " + s.Name)
+			s.send("id author " + s.Author)
+			s.send(fmt.Sprintf("option name Hash type spin default %d min 1 max 4096", DefaultHashMB))
+			s.send("option name Clear Hash type button")
+			s.send("uciok")
+		case "isready":
+			s.send("readyok")
+		case "setoption":
+			s.stop()
+			s.setOption(args)
+		case "ucinewgame":
+			s.stop()
+			s.engine.Clear()
+		case "position":
+			s.stop()
+			if err := s.setPosition(args); err != nil {
+				s.send("info string " + err.Error())
+			}
+		case "go":
+			s.stop()
+			limits, infinite, err := s.parseGo(args)
+			if err != nil {
+				s.send("info string " + err.Error())
+				continue
+			}
+			s.start(limits, infinite)
+		case "stop":
+			s.stop()
+		case "quit":
+			return nil
+		case "debug", "ponderhit", "register":
+		default:
+			s.send("info string unknown command " + cmd)
+		}
+	}
+	return scanner.Err()
+}
+
+func (s *Server) send(line string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fmt.Fprintln(s.out, line)
+}
+
+// start searches a copy of the current position in the background. In
+// infinite mode the best move is held back until the search is stopped.
+func (s *Server) start(limits chessboard.SearchLimits, infinite bool) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	s.cancel, s.done = cancel, done
+	p := *s.position
+	history := slices.Clone(s.history)
+	s.engine.Info = func(info chessboard.SearchInfo) {
+		s.send(formatInfo(info))
+	}
+	go func() {
+		defer close(done)
+		info := s.engine.Search(ctx, &p, history, limits)
+		if infinite {
+			<-ctx.Done()
+		}
+		best := "0000"
+		if len(info.PV) > 0 {
+			best = info.PV[0].String()
+		}
+		s.send("bestmove " + best)
+	}()
+}
+
+// stop ends the running search, if any, once it has sent its best move.
+func (s *Server) stop() {
+	if s.cancel == nil {
+		return
+	}
+	s.cancel()
+	<-s.done
+	s.cancel, s.done = nil, nil
+}
+
+func formatInfo(info chessboard.SearchInfo) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "info depth %d score ", info.Depth)
+	if info.Mate != 0 {
+		fmt.Fprintf(&b, "mate %d", info.Mate)
+	} else {
+		fmt.Fprintf(&b, "cp %d", info.Score)
+	}
+	nps := int64(0)
+	if info.Time > 0 {
+		nps = int64(float64(info.Nodes) / info.Time.Seconds())
+	}
+	fmt.Fprintf(&b, " nodes %d nps %d time %d pv", info.Nodes, nps, info.Time.Milliseconds())
+	for _, m := range info.PV {
+		b.WriteString(" " + m.String())
+	}
+	return b.String()
+}
+
+func (s *Server) setOption(args []string) {
+	// setoption name <id> [value <x>], where the name may contain spaces.
+	joined := strings.Join(args, " ")
+	name, value, _ := strings.Cut(strings.TrimPrefix(joined, "name "), " value ")
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "hash":
+		mb, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || mb < 1 {
+			s.send(fmt.Sprintf("info string invalid Hash value %q", value))
+			return
+		}
+		s.engine.Resize(mb)
+	case "clear hash":
+		s.engine.Clear()
+	default:
+		s.send(fmt.Sprintf("info string unknown option %q", name))
+	}
+}
+
+// setPosition handles "position (startpos | fen <fen>) [moves <move>...]".
+// The current position is only replaced when every move is legal.
+func (s *Server) setPosition(args []string) error {
+	var fen string
+	switch {
+	case len(args) > 0 && args[0] == "startpos":
+		fen, args = chessboard.StartFEN, args[1:]
+	case len(args) > 0 && args[0] == "fen":
+		end := slices.Index(args, "moves")
+		if end < 0 {
+			end = len(args)
+		}
+		fen, args = strings.Join(args[1:end], " "), args[end:]
+	default:
+		return fmt.Errorf("position needs startpos or fen")
+	}
+	p, err := chessboard.ParseFEN(fen)
+	if err != nil {
+		return err
+	}
+	var history []uint64
+	if len(args) > 0 {
+		if args[0] != "moves" {
+			return fmt.Errorf("unexpected %q after the position", args[0])
+		}
+		for _, text := range args[1:] {
+			m, err := parseMove(p, text)
+			if err != nil {
+				return err
+			}
+			history = append(history, p.Hash())
+			p.MakeMove(m)
+		}
+	}
+	s.position, s.history = p, history
+	return nil
+}
+
+// parseMove finds the legal move written in UCI notation, e.g. "e7e8q".
+func parseMove(p *chessboard.Position, text string) (chessboard.Move, error) {
+	for _, m := range p.LegalMoves() {
+		if m.String() == text {
+			return m, nil
+		}
+	}
+	return chessboard.Move{}, fmt.Errorf("%w: %s in %s", chessboard.ErrIllegalMove, text, p.FEN())
+}
+
+// isMove reports whether text looks like a move in UCI notation, e.g.
+// "e2e4" or "e7e8q", as opposed to a go parameter.
+func isMove(text string) bool {
+	square := func(s string) bool { return s[0] >= 'a' && s[0] <= 'h' && s[1] >= '1' && s[1] <= '8' }
+	switch len(text) {
+	case 4:
+		return square(text[:2]) && square(text[2:])
+	case 5:
+		return square(text[:2]) && square(text[2:4]) && strings.ContainsRune("qrbn", rune(text[4]))
+	}
+	return false
+}
+
+// parseGo reads the arguments of "go". Time values are in milliseconds.
+func (s *Server) parseGo(args []string) (chessboard.SearchLimits, bool, error) {
+	var limits chessboard.SearchLimits
+	infinite := false
+	for i := 0; i < len(args); i++ {
+		key := args[i]
+		switch key {
+		case "infinite":
+			infinite = true
+			continue
+		case "ponder":
+			// The engine does not offer the Ponder option.
+			return limits, false, fmt.Errorf("pondering is not supported")
+		case "searchmoves":
+			// Restricting the root moves is not supported; skip the list.
+			for i+1 < len(args) && isMove(args[i+1]) {
+				i++
+			}
+			continue
+		}
+		if i+1 >= len(args) {
+			return limits, false, fmt.Errorf("go %s needs a value", key)
+		}
+		i++
+		n, err := strconv.ParseInt(args[i], 10, 64)
+		if err != nil {
+			return limits, false, fmt.Errorf("go %s: invalid value %q", key, args[i])
+		}
+		ms := time.Duration(n) * time.Millisecond
+		switch key {
+		case "depth":
+			limits.Depth = int(n)
+		case "nodes":
+			limits.Nodes = n
+		case "movetime":
+			limits.MoveTime = ms
+		case "wtime":
+			limits.Time[chessboard.White] = ms
+		case "btime":
+			limits.Time[chessboard.Black] = ms
+		case "winc":
+			limits.Increment[chessboard.White] = ms
+		case "binc":
+			limits.Increment[chessboard.Black] = ms
+		case "movestogo":
+			limits.MovesToGo = int(n)
+		case "mate":
+			limits.Depth = int(2*n - 1)
+		default:
+			return limits, false, fmt.Errorf("unknown go parameter %q", key)
+		}
+	}
+	return limits, infinite, nil
+}
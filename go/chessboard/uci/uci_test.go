@@ -0,0 +1,218 @@
+package uci
+
+import (
+	"bufio"
+	"chessboard"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// session drives a Server through pipes, the way a GUI would.
+type session struct {
+	t     *testing.T
+	in    *io.PipeWriter
+	lines chan string
+	done  chan error
+}
+
+func newSession(t *testing.T) *session {
+	t.Helper()
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	s := &session{t: t, in: inW, lines: make(chan string, 100), done: make(chan error, 1)}
+	go func() {
+		err := NewServer().Run(inR, outW)
+		outW.Close()
+		s.done <- err
+	}()
+	go func() {
+		scanner := bufio.NewScanner(outR)
+		for scanner.Scan() {
+			s.lines <- scanner.Text()
+		}
+		close(s.lines)
+	}()
+	t.Cleanup(func() {
+		inW.Close()
+		select {
+		case err := <-s.done:
+			if err != nil {
+				t.Errorf("Run() error = %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Errorf("Run() did not return after the input closed")
+		}
+	})
+	return s
+}
+
+func (s *session) send(lines ...string) {
+	s.t.Helper()
+	for _, line := range lines {
+		if _, err := io.WriteString(s.in, line+"\n"); err != nil {
+			s.t.Fatalf("writing %q: %v", line, err)
+		}
+	}
+}
+
+// expect reads output until a line starting with prefix, and returns the
+// lines read, that one included.
+func (s *session) expect(prefix string) []string {
+	s.t.Helper()
+	var seen []string
+	timeout := time.After(10 * time.Second)
+	for {
+		select {
+		case line, ok := <-s.lines:
+			if !ok {
+				s.t.Fatalf("output ended before %q; got %q", prefix, seen)
+			}
+			seen = append(seen, line)
+			if strings.HasPrefix(line, prefix) {
+				return seen
+			}
+		case <-timeout:
+			s.t.Fatalf("no %q after 10s; got %q", prefix, seen)
+		}
+	}
+}
+
+func TestHandshake(t *testing.T) {
+	s := newSession(t)
+	s.send("uci")
+	lines := s.expect("uciok")
+	if lines[0] != "id name chessboard" || !strings.HasPrefix(lines[1], "id author ") {
+		t.Errorf("uci reply = %q", lines)
+	}
+	if !strings.Contains(strings.Join(lines, "\n"), "option name Hash type spin") {
+		t.Errorf("uci reply does not offer the Hash option: %q", lines)
+	}
+	s.send("setoption name Hash value 2", "ucinewgame", "isready")
+	if lines := s.expect("readyok"); len(lines) != 1 {
+		t.Errorf("isready after setoption = %q, want only readyok", lines)
+	}
+}
+
+func TestSearchSession(t *testing.T) {
+	testCases := []struct {
+		name     string
+		position string
+		want     string
+	}{
+		{name: "mate in one", position: "position fen 6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", want: "bestmove a1a8"},
+		{name: "after moves", position: "position fen 4k3/8/8/8/8/8/3q4/3RK3 b - - 0 1 moves d2d5", want: "bestmove d1d5"},
+		{name: "promotion", position: "position fen 8/P6k/8/8/8/8/8/K7 w - - 0 1", want: "bestmove a7a8q"},
+		{name: "no legal move", position: "position fen 7k/5Q2/6K1/8/8/8/8/8 b - - 0 1", want: "bestmove 0000"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newSession(t)
+			s.send(tc.position, "go depth 3")
+			lines := s.expect("bestmove")
+			if got := lines[len(lines)-1]; got != tc.want {
+				t.Errorf("got %q, want %q (output %q)", got, tc.want, lines)
+			}
+		})
+	}
+}
+
+func TestInfoLines(t *testing.T) {
+	s := newSession(t)
+	s.send("position startpos moves e2e4 e7e5", "go depth 3")
+	lines := s.expect("bestmove")
+	if len(lines) != 4 {
+		t.Fatalf("go depth 3 output = %q, want three info lines and bestmove", lines)
+	}
+	for i, line := range lines[:3] {
+		fields := strings.Fields(line)
+		if len(fields) < 14 || fields[0] != "info" || fields[2] != strconv.Itoa(i+1) ||
+			fields[3] != "score" || fields[4] != "cp" || fields[12] != "pv" {
+			t.Errorf("info line %d = %q", i+1, line)
+		}
+	}
+}
+
+func TestStopInfinite(t *testing.T) {
+	s := newSession(t)
+	s.send("position startpos", "go infinite")
+	s.expect("info depth 1 ")
+	s.send("isready")
+	s.expect("readyok")
+	s.send("stop")
+	lines := s.expect("bestmove")
+	if best := lines[len(lines)-1]; len(best) < len("bestmove e2e4") {
+		t.Errorf("bestmove after stop = %q", best)
+	}
+}
+
+func TestTimeControl(t *testing.T) {
+	s := newSession(t)
+	start := time.Now()
+	s.send("position startpos", "go wtime 1000 btime 1000 winc 0 binc 0")
+	s.expect("bestmove")
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("search with 1s on the clock took %v", elapsed)
+	}
+	start = time.Now()
+	s.send("go movetime 100")
+	s.expect("bestmove")
+	if elapsed := time.Since(start); elapsed > 400*time.Millisecond {
+		t.Errorf("go movetime 100 took %v", elapsed)
+	}
+}
+
+func TestQuitStopsSearch(t *testing.T) {
+	s := newSession(t)
+	s.send("go infinite", "quit")
+	s.expect("bestmove")
+	select {
+	case err := <-s.done:
+		if err != nil {
+			t.Errorf("Run() error = %v", err)
+		}
+		s.done <- nil
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run() did not return after quit")
+	}
+}
+
+func TestBadCommands(t *testing.T) {
+	s := newSession(t)
+	for _, tc := range []struct{ command, want string }{
+		{command: "position fen 8/8 w - -", want: "info string invalid FEN"},
+		{command: "position startpos moves e2e5", want: "info string illegal move"},
+		{command: "go depth x", want: `info string go depth: invalid value "x"`},
+		{command: "go ponder", want: "info string pondering is not supported"},
+		{command: "setoption name Threads value 4", want: `info string unknown option "Threads"`},
+		{command: "frobnicate", want: "info string unknown command frobnicate"},
+	} {
+		s.send(tc.command)
+		lines := s.expect("info string")
+		if got := lines[len(lines)-1]; !strings.HasPrefix(got, tc.want) {
+			t.Errorf("%s: got %q, want %q", tc.command, got, tc.want)
+		}
+	}
+	// A failed position command keeps the previous position.
+	s.send("position fen 6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", "position startpos moves e2e5", "go depth 2")
+	lines := s.expect("bestmove")
+	if got := lines[len(lines)-1]; got != "bestmove a1a8" {
+		t.Errorf("bestmove after a failed position = %q, want bestmove a1a8", got)
+	}
+}
+
+func TestParseGoSearchMoves(t *testing.T) {
+	s := NewServer()
+	// The move list ends at the first parameter, even one starting with
+	// a file letter.
+	limits, _, err := s.parseGo(strings.Fields("searchmoves e2e4 d2d4 depth 5"))
+	if err != nil || limits.Depth != 5 {
+		t.Errorf("searchmoves then depth: limits %+v, error %v", limits, err)
+	}
+	limits, _, err = s.parseGo(strings.Fields("searchmoves e7e8q btime 900 binc 10"))
+	if err != nil || limits.Time[chessboard.Black] != 900*time.Millisecond || limits.Increment[chessboard.Black] != 10*time.Millisecond {
+		t.Errorf("searchmoves then btime: limits %+v, error %v", limits, err)
+	}
+}
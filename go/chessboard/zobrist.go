@@ -0,0 +1,42 @@
+package chessboard
+
+// Zobrist keys: one random number per piece on each square, per set of
+// castling rights, per en-passant file and for black to move.
+var (
+	pieceKeys     [2][7][64]uint64
+	castlingKeys  [16]uint64
+	enPassantKeys [8]uint64
+	blackToMove   uint64
+)
+
+func init() {
+	rng := xorshift(0x2545f4914f6cdd1d)
+	for c := range pieceKeys {
+		for k := Pawn; k <= King; k++ {
+			for s := range pieceKeys[c][k] {
+				pieceKeys[c][k][s] = rng.next()
+			}
+		}
+	}
+	for i := range castlingKeys {
+		castlingKeys[i] = rng.next()
+	}
+	for i := range enPassantKeys {
+		enPassantKeys[i] = rng.next()
+	}
+	blackToMove = rng.next()
+}
+
+// Hash returns the Zobrist hash of the position. It covers the pieces,
+// the side to move, castling rights and the en-passant square, but not
+// the move clocks.
+func (p *Position) Hash() uint64 {
+	h := p.pieceHash ^ castlingKeys[p.Castling]
+	if p.EnPassant != NoSquare {
+		h ^= enPassantKeys[p.EnPassant.File()]
+	}
+	if p.SideToMove == Black {
+		h ^= blackToMove
+	}
+	return h
+}
@@ -0,0 +1,181 @@
+package electionday
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ErrTamperedLog is matched by every AuditError.
+var ErrTamperedLog = errors.New("audit log does not verify")
+
+// AuditError says which entry of an audit log fails to verify.
+type AuditError struct {
+	// Seq is the sequence number the entry should have.
+	Seq    int
+	Reason string
+}
+
+func (e *AuditError) Error() string {
+	return fmt.Sprintf("%v: entry %d: %s", ErrTamperedLog, e.Seq, e.Reason)
+}
+
+// Is makes errors.Is(err, ErrTamperedLog) match.
+func (e *AuditError) Is(target error) bool {
+	return target == ErrTamperedLog
+}
+
+// AuditEntry records one change to a count. Hash covers every other field,
+// Prev included, so changing, removing or reordering entries breaks the
+// chain of hashes.
+type AuditEntry struct {
+	Seq     int            `json:"seq"`
+	Time    time.Time      `json:"time"`
+	Station string         `json:"station,omitempty"`
+	Reason  string         `json:"reason"`
+	Votes   map[string]int `json:"votes"`
+	// Prev is the hash of the entry before, "" for the first one.
+	Prev string `json:"prev"`
+	Hash string `json:"hash"`
+}
+
+// sum returns the SHA-256 hash of the entry, in hex.
+func (e AuditEntry) sum() string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%d\n%s\n%q\n%q\n", e.Seq, e.Time.UTC().Format(time.RFC3339Nano), e.Station, e.Reason)
+	for _, c := range slices.Sorted(maps.Keys(e.Votes)) {
+		fmt.Fprintf(h, "%q %d\n", c, e.Votes[c])
+	}
+	fmt.Fprintf(h, "%s\n", e.Prev)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// AuditLog is an append-only, hash-chained record of changes to a tally.
+// It is safe for concurrent use.
+type AuditLog struct {
+	mu      sync.Mutex
+	entries []AuditEntry
+	enc     *json.Encoder
+	err     error
+	now     func() time.Time
+}
+
+// NewAuditLog returns an empty log that also writes every entry to w as a
+// line of JSON, unless w is nil.
+func NewAuditLog(w io.Writer) *AuditLog {
+	l := &AuditLog{now: time.Now}
+	if w != nil {
+		l.enc = json.NewEncoder(w)
+	}
+	return l
+}
+
+// Append adds an entry for the given votes and returns it. A failure to
+// write the entry out is kept for Err; the entry is logged regardless.
+func (l *AuditLog) Append(station, reason string, votes map[string]int) AuditEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	e := AuditEntry{
+		Seq:     len(l.entries) + 1,
+		Time:    l.now().UTC().Round(0),
+		Station: station,
+		Reason:  reason,
+		Votes:   maps.Clone(votes),
+	}
+	if len(l.entries) > 0 {
+		e.Prev = l.entries[len(l.entries)-1].Hash
+	}
+	e.Hash = e.sum()
+	l.entries = append(l.entries, e)
+	if l.enc != nil && l.err == nil {
+		l.err = l.enc.Encode(e)
+	}
+	return e
+}
+
+// Entries returns a copy of the entries so far.
+func (l *AuditLog) Entries() []AuditEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return slices.Clone(l.entries)
+}
+
+// Head returns the hash of the last entry, "" for an empty log. Keeping it
+// apart from the log is what makes removing entries from the end show.
+func (l *AuditLog) Head() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.entries) == 0 {
+		return ""
+	}
+	return l.entries[len(l.entries)-1].Hash
+}
+
+// Err returns the first error writing entries out, if any.
+func (l *AuditLog) Err() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.err
+}
+
+// ReadAuditLog reads the entries written by an AuditLog. It does not
+// verify them.
+func ReadAuditLog(r io.Reader) ([]AuditEntry, error) {
+	var entries []AuditEntry
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		var e AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("audit log line %d: %w", line, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+// VerifyAuditLog checks the chain of hashes, returning an *AuditError for
+// the first entry that was modified, or that follows entries removed or
+// moved. Removing entries from the end only shows against the head hash
+// recorded at the time; pass "" to skip that check.
+func VerifyAuditLog(entries []AuditEntry, head string) error {
+	prev := ""
+	for i, e := range entries {
+		switch {
+		case e.Hash != e.sum():
+			return &AuditError{Seq: i + 1, Reason: "hash does not match the contents"}
+		case e.Prev != prev:
+			return &AuditError{Seq: i + 1, Reason: "does not follow the entry before"}
+		case e.Seq != i+1:
+			return &AuditError{Seq: i + 1, Reason: fmt.Sprintf("has sequence number %d", e.Seq)}
+		}
+		prev = e.Hash
+	}
+	if head != "" && prev != head {
+		return &AuditError{Seq: len(entries) + 1, Reason: "log ends before the recorded head"}
+	}
+	return nil
+}
+
+// RebuildResults verifies a log and replays it, returning the results it
+// leads to, most votes first and then by name.
+func RebuildResults(entries []AuditEntry, head string) ([]*ElectionResult, error) {
+	if err := VerifyAuditLog(entries, head); err != nil {
+		return nil, err
+	}
+	votes := make(map[string]int)
+	for _, e := range entries {
+		for c, n := range e.Votes {
+			votes[c] += n
+		}
+	}
+	return sortedResults(votes), nil
+}
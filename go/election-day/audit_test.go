@@ -0,0 +1,181 @@
+package electionday
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// auditedTally returns a tally whose log has a few entries of each kind,
+// with timestamps one second apart.
+func auditedTally(t *testing.T, w *bytes.Buffer) (*Tally, *AuditLog) {
+	t.Helper()
+	log := NewAuditLog(w)
+	clock := time.Date(2024, 11, 5, 7, 0, 0, 0, time.UTC)
+	log.now = func() time.Time {
+		clock = clock.Add(time.Second)
+		return clock
+	}
+	tally := NewAuditedTally(log)
+	tally.IncrementVoteCount("Mary", 3)
+	if _, err := tally.SubmitBatch("north-1", map[string]int{"John": 10, "Mary": 7}); err != nil {
+		t.Fatal(err)
+	}
+	tally.SubmitBatch("north-1", map[string]int{"John": 10, "Mary": 7}) // a resubmission changes nothing
+	tally.DecrementVotesOfCandidate("John")
+	tally.DecrementVotesOfCandidate("Nobody")
+	tally.Adjust("south-2", "recount", map[string]int{"John": 2, "Mary": -1})
+	return tally, log
+}
+
+func TestAuditLogRecordsChanges(t *testing.T) {
+	var buf bytes.Buffer
+	tally, log := auditedTally(t, &buf)
+	entries := log.Entries()
+
+	var reasons []string
+	for _, e := range entries {
+		reasons = append(reasons, e.Station+"/"+e.Reason)
+	}
+	want := []string{"/IncrementVoteCount", "north-1/SubmitBatch", "/DecrementVotesOfCandidate", "south-2/recount"}
+	if !slices.Equal(reasons, want) {
+		t.Errorf("entries = %q, want %q", reasons, want)
+	}
+	if got := entries[3].Time; !got.Equal(time.Date(2024, 11, 5, 7, 0, 4, 0, time.UTC)) {
+		t.Errorf("last entry time = %v", got)
+	}
+	if err := VerifyAuditLog(entries, log.Head()); err != nil {
+		t.Errorf("VerifyAuditLog() error = %v", err)
+	}
+
+	// The log written out reads back, verifies and rebuilds the results.
+	read, err := ReadAuditLog(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := RebuildResults(read, log.Head())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := displayResults(results), displayResults(tally.Results()); !slices.Equal(got, want) {
+		t.Errorf("RebuildResults() = %q, want %q", got, want)
+	}
+	if got := displayResults(results); !slices.Equal(got, []string{"John (11)", "Mary (9)"}) {
+		t.Errorf("RebuildResults() = %q", got)
+	}
+}
+
+func displayResults(results []*ElectionResult) []string {
+	var display []string
+	for _, r := range results {
+		display = append(display, DisplayResult(r))
+	}
+	return display
+}
+
+func TestVerifyAuditLogDetectsTampering(t *testing.T) {
+	_, log := auditedTally(t, new(bytes.Buffer))
+	head := log.Head()
+	tests := []struct {
+		name   string
+		tamper func([]AuditEntry) []AuditEntry
+		seq    int
+	}{
+		{
+			name: "modified votes",
+			tamper: func(es []AuditEntry) []AuditEntry {
+				es[1].Votes = map[string]int{"John": 100, "Mary": 7}
+				return es
+			},
+			seq: 2,
+		},
+		{
+			name: "modified station",
+			tamper: func(es []AuditEntry) []AuditEntry {
+				es[3].Station = "north-1"
+				return es
+			},
+			seq: 4,
+		},
+		{
+			name: "rehashed entry",
+			tamper: func(es []AuditEntry) []AuditEntry {
+				es[0].Votes = map[string]int{"Mary": 30}
+				es[0].Hash = es[0].sum()
+				return es
+			},
+			seq: 2,
+		},
+		{
+			name:   "removed entry",
+			tamper: func(es []AuditEntry) []AuditEntry { return slices.Delete(es, 1, 2) },
+			seq:    2,
+		},
+		{
+			name: "reordered entries",
+			tamper: func(es []AuditEntry) []AuditEntry {
+				es[2], es[3] = es[3], es[2]
+				return es
+			},
+			seq: 3,
+		},
+		{
+			name:   "truncated log",
+			tamper: func(es []AuditEntry) []AuditEntry { return es[:3] },
+			seq:    4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries := tt.tamper(log.Entries())
+			err := VerifyAuditLog(entries, head)
+			var auditErr *AuditError
+			if !errors.As(err, &auditErr) || !errors.Is(err, ErrTamperedLog) || auditErr.Seq != tt.seq {
+				t.Fatalf("VerifyAuditLog() error = %v, want an *AuditError at entry %d", err, tt.seq)
+			}
+			if _, err := RebuildResults(entries, head); !errors.Is(err, ErrTamperedLog) {
+				t.Errorf("RebuildResults() error = %v, want ErrTamperedLog", err)
+			}
+		})
+	}
+}
+
+func TestAuditLogConcurrent(t *testing.T) {
+	log := NewAuditLog(nil)
+	tally := NewAuditedTally(log)
+	var wg sync.WaitGroup
+	for i := range 100 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			candidate := []string{"John", "Mary"}[i%2]
+			for range 20 {
+				tally.IncrementVoteCount(candidate, 1)
+			}
+			for range 5 {
+				tally.DecrementVotesOfCandidate(candidate)
+			}
+		}()
+	}
+	wg.Wait()
+	// Every change is logged in the order it was applied, so replaying
+	// the log never takes a candidate below zero.
+	running := make(map[string]int)
+	for _, e := range log.Entries() {
+		for name, n := range e.Votes {
+			if running[name] += n; running[name] < 0 {
+				t.Fatalf("entry %d takes %s to %d", e.Seq, name, running[name])
+			}
+		}
+	}
+	results, err := RebuildResults(log.Entries(), log.Head())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := displayResults(results); !slices.Equal(got, []string{"John (750)", "Mary (750)"}) {
+		t.Errorf("RebuildResults() = %q", got)
+	}
+}
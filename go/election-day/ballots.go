@@ -0,0 +1,224 @@
+package electionday
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+)
+
+// ErrInvalidBallotFile is matched by every BallotFileError.
+var ErrInvalidBallotFile = errors.New("invalid ballot file")
+
+// BallotFileError describes a ballot file that cannot be read any further,
+// as opposed to a single bad ballot, which is only reported.
+type BallotFileError struct {
+	Format string
+	// Line is 1-based, or 0 when the format has no useful line numbers.
+	Line   int
+	Reason string
+}
+
+func (e *BallotFileError) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("%v: %s: %s", ErrInvalidBallotFile, e.Format, e.Reason)
+	}
+	return fmt.Sprintf("%v: %s line %d: %s", ErrInvalidBallotFile, e.Format, e.Line, e.Reason)
+}
+
+// Is makes errors.Is(err, ErrInvalidBallotFile) match.
+func (e *BallotFileError) Is(target error) bool {
+	return target == ErrInvalidBallotFile
+}
+
+// ProblemKind says why a ballot was left out of the count.
+type ProblemKind int
+
+const (
+	// InvalidBallot is a ballot that cannot be understood, e.g. one
+	// naming an unknown candidate or ranking two candidates equal.
+	InvalidBallot ProblemKind = iota
+	// DuplicateBallot is a ballot whose ID was already seen.
+	DuplicateBallot
+)
+
+func (k ProblemKind) String() string {
+	if k == DuplicateBallot {
+		return "duplicate"
+	}
+	return "invalid"
+}
+
+// BallotProblem is a ballot left out of the count.
+type BallotProblem struct {
+	Kind ProblemKind
+	// Ballot is the 1-based position of the ballot in the file.
+	Ballot int
+	// Line is 1-based, or 0 when the format has no useful line numbers.
+	Line int
+	// ID is the ballot or respondent ID, if the file has one.
+	ID     string
+	Reason string
+}
+
+func (p BallotProblem) String() string {
+	where := fmt.Sprintf("ballot %d", p.Ballot)
+	if p.Line > 0 {
+		where += fmt.Sprintf(" (line %d)", p.Line)
+	}
+	if p.ID != "" {
+		where += fmt.Sprintf(" %q", p.ID)
+	}
+	return fmt.Sprintf("%s: %s: %s", where, p.Kind, p.Reason)
+}
+
+// BallotReport sums up the ballots read from a file. Exhausted ballots,
+// which rank no candidate that is standing, are left out of the count
+// without being a problem.
+type BallotReport struct {
+	Valid, Exhausted int
+	Problems         []BallotProblem
+}
+
+// Count returns the number of ballots left out for the given reason.
+func (r BallotReport) Count(kind ProblemKind) int {
+	n := 0
+	for _, p := range r.Problems {
+		if p.Kind == kind {
+			n++
+		}
+	}
+	return n
+}
+
+// BallotReader reads ballots one at a time. Readers only return valid,
+// non-exhausted ballots; the others are recorded in the report.
+type BallotReader interface {
+	// Next returns the next ballot, io.EOF at the end of the file, or a
+	// *BallotFileError when the file itself is malformed.
+	Next() (Ballot, error)
+	// Candidates returns the candidates in file order. Formats that do
+	// not declare them up front only know them all after io.EOF.
+	Candidates() []string
+	// Seats returns the number of seats the file declares, 0 if none.
+	Seats() int
+	// Report returns what has been read so far.
+	Report() BallotReport
+}
+
+// ballotLog does the bookkeeping shared by the readers.
+type ballotLog struct {
+	report BallotReport
+	ids    map[string]bool
+	n      int
+}
+
+// Report implements BallotReader for the readers embedding the log.
+func (l *ballotLog) Report() BallotReport {
+	report := l.report
+	report.Problems = slices.Clone(report.Problems)
+	return report
+}
+
+// next starts a new ballot.
+func (l *ballotLog) next() {
+	l.n++
+}
+
+func (l *ballotLog) invalid(line int, id, format string, args ...any) {
+	l.report.Problems = append(l.report.Problems, BallotProblem{
+		Kind: InvalidBallot, Ballot: l.n, Line: line, ID: id, Reason: fmt.Sprintf(format, args...),
+	})
+}
+
+// accept records a ballot and reports whether it should be counted: it
+// must not repeat an ID or a candidate, and must rank someone.
+func (l *ballotLog) accept(b Ballot, line int, id string) bool {
+	if id != "" {
+		if l.ids[id] {
+			l.report.Problems = append(l.report.Problems, BallotProblem{
+				Kind: DuplicateBallot, Ballot: l.n, Line: line, ID: id, Reason: "ID already seen",
+			})
+			return false
+		}
+		if l.ids == nil {
+			l.ids = make(map[string]bool)
+		}
+		l.ids[id] = true
+	}
+	seen := make(map[string]bool, len(b.Ranking))
+	for _, c := range b.Ranking {
+		if seen[c] {
+			l.invalid(line, id, "ranks %q twice", c)
+			return false
+		}
+		seen[c] = true
+	}
+	if len(b.Ranking) == 0 {
+		l.report.Exhausted++
+		return false
+	}
+	l.report.Valid++
+	return true
+}
+
+// ReadElection reads every ballot into an election.
+func ReadElection(r BallotReader) (Election, BallotReport, error) {
+	var ballots []Ballot
+	for {
+		b, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return Election{}, r.Report(), err
+		}
+		ballots = append(ballots, b)
+	}
+	return Election{Candidates: r.Candidates(), Ballots: ballots, Seats: r.Seats()}, r.Report(), nil
+}
+
+// Load streams the first preferences of every ballot into the tally, so
+// files of any size are counted in constant memory. NewBLTReader has to
+// read the whole file first; use LoadBLT for large BLT files.
+func (t *Tally) Load(r BallotReader) (BallotReport, error) {
+	for {
+		b, err := r.Next()
+		if err == io.EOF {
+			return r.Report(), nil
+		}
+		if err != nil {
+			return r.Report(), err
+		}
+		t.IncrementVoteCount(b.Ranking[0], int(b.weight()))
+	}
+}
+
+// LoadBLT streams the first preferences of a BLT file into the tally. A
+// BLT file names the candidates after the ballots, so the votes are added
+// up by candidate number as the ballots are read, and added to the tally
+// in one change once the names are known; nothing is added if the file
+// is malformed. See BLTReader for the format.
+func (t *Tally) LoadBLT(r io.Reader) (BallotReport, error) {
+	var log ballotLog
+	p, err := newBLTParser(r)
+	if err != nil {
+		return log.Report(), err
+	}
+	firsts := make([]int, p.n+1)
+	if err := p.ballots(&log, func(b bltBallot) { firsts[b.ranking[0]] += b.weight }); err != nil {
+		return log.Report(), err
+	}
+	names, _, err := p.names()
+	if err != nil {
+		return log.Report(), err
+	}
+	votes := make(map[string]int)
+	for c, n := range firsts[1:] {
+		if n > 0 {
+			votes[names[c]] += n
+		}
+	}
+	t.apply("", "LoadBLT", votes)
+	return log.Report(), nil
+}
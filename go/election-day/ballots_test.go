@@ -0,0 +1,328 @@
+package electionday
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const sampleBLT = `# Board election
+4 2
+-4
+3 1 2 0
+(b2) 2 2 - 3 0
+(b3) 1 4 3 0    # Dave is withdrawn: counts for Carol
+1 4 0           # only Dave: exhausted
+1 1 9 0
+1 1=2 0
+(b2) 1 3 0
+1 3 3 0
+0
+"Alice" "Bob"
+"Carol"
+"Dave"
+"Board 2024"
+`
+
+func TestBLTReader(t *testing.T) {
+	br, err := NewBLTReader(strings.NewReader(sampleBLT))
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, report, err := ReadElection(br)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(e.Candidates, []string{"Alice", "Bob", "Carol"}) || e.Seats != 2 || br.Title != "Board 2024" {
+		t.Errorf("candidates %v, seats %d, title %q", e.Candidates, e.Seats, br.Title)
+	}
+	want := []Ballot{
+		{Ranking: []string{"Alice", "Bob"}, Weight: 3},
+		{Ranking: []string{"Bob", "Carol"}, Weight: 2},
+		{Ranking: []string{"Carol"}, Weight: 1},
+	}
+	if !slices.EqualFunc(e.Ballots, want, func(a, b Ballot) bool {
+		return a.Weight == b.Weight && slices.Equal(a.Ranking, b.Ranking)
+	}) {
+		t.Errorf("ballots = %v, want %v", e.Ballots, want)
+	}
+	checkReport(t, report, 3, 1, []string{
+		`ballot 5 (line 8): invalid: unknown candidate "9"`,
+		`ballot 6 (line 9): invalid: equal preferences "1=2"`,
+		`ballot 7 (line 10) "b2": duplicate: ID already seen`,
+		`ballot 8 (line 11): invalid: ranks "3" twice`,
+	})
+}
+
+func checkReport(t *testing.T, report BallotReport, valid, exhausted int, problems []string) {
+	t.Helper()
+	if report.Valid != valid || report.Exhausted != exhausted {
+		t.Errorf("report counts %d valid and %d exhausted ballots, want %d and %d", report.Valid, report.Exhausted, valid, exhausted)
+	}
+	var got []string
+	for _, p := range report.Problems {
+		got = append(got, p.String())
+	}
+	if !slices.Equal(got, problems) {
+		t.Errorf("problems:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(problems, "\n"))
+	}
+}
+
+func TestBLTErrors(t *testing.T) {
+	tests := []struct {
+		name, blt string
+	}{
+		{name: "empty", blt: ""},
+		{name: "bad header", blt: "two seats\n"},
+		{name: "more seats than candidates", blt: "2 3\n0\n\"A\" \"B\"\n"},
+		{name: "no end of ballots", blt: "2 1\n1 1 0\n"},
+		{name: "missing names", blt: "2 1\n1 1 0\n0\n\"A\"\n"},
+		{name: "unquoted name", blt: "2 1\n0\nA B\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewBLTReader(strings.NewReader(tt.blt))
+			var fileErr *BallotFileError
+			if !errors.As(err, &fileErr) || !errors.Is(err, ErrInvalidBallotFile) || fileErr.Format != "BLT" {
+				t.Errorf("error = %v, want a BLT *BallotFileError", err)
+			}
+		})
+	}
+}
+
+func TestCSVReader(t *testing.T) {
+	tests := []struct {
+		name       string
+		csv        string
+		candidates []string
+		ballots    [][]string
+		problems   []string
+	}{
+		{
+			name: "grid",
+			csv: `Timestamp,Respondent ID,Rank the candidates [Alice],Rank the candidates [Bob],Rank the candidates [Carol]
+2024-05-01 10:00,r1,1,2,
+2024-05-01 10:01,r2,,3,1
+2024-05-01 10:02,r3,1,1,2
+2024-05-01 10:03,r1,2,1,
+2024-05-01 10:04,r4,,,
+2024-05-01 10:05,r5,first,,
+`,
+			candidates: []string{"Alice", "Bob", "Carol"},
+			ballots:    [][]string{{"Alice", "Bob"}, {"Carol", "Bob"}},
+			problems: []string{
+				`ballot 3 (line 4) "r3": invalid: "Alice" and "Bob" both ranked 1`,
+				`ballot 4 (line 5) "r1": duplicate: ID already seen`,
+				`ballot 6 (line 7) "r5": invalid: bad rank "first" for "Alice"`,
+			},
+		},
+		{
+			name: "preferences",
+			csv: `ID,1st choice,2nd choice,3rd choice
+1,Bob,Alice,
+2,"Carol, Jr.",,Bob
+3,Alice,Alice,
+4,,,
+`,
+			candidates: []string{"Bob", "Alice", "Carol, Jr."},
+			ballots:    [][]string{{"Bob", "Alice"}, {"Carol, Jr.", "Bob"}},
+			problems:   []string{`ballot 3 (line 4) "3": invalid: ranks "Alice" twice`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cr, err := NewCSVReader(strings.NewReader(tt.csv))
+			if err != nil {
+				t.Fatal(err)
+			}
+			e, report, err := ReadElection(cr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(e.Candidates, tt.candidates) {
+				t.Errorf("candidates = %q, want %q", e.Candidates, tt.candidates)
+			}
+			var got [][]string
+			for _, b := range e.Ballots {
+				got = append(got, b.Ranking)
+			}
+			if !slices.EqualFunc(got, tt.ballots, slices.Equal) {
+				t.Errorf("ballots = %q, want %q", got, tt.ballots)
+			}
+			checkReport(t, report, len(tt.ballots), 1, tt.problems)
+		})
+	}
+}
+
+func TestCSVErrors(t *testing.T) {
+	for _, csv := range []string{
+		"",
+		"Timestamp,Name\n",
+		"Rank 1,Vote [Alice]\n",
+		"Vote [Alice],Vote [Alice]\n",
+	} {
+		if _, err := NewCSVReader(strings.NewReader(csv)); !errors.Is(err, ErrInvalidBallotFile) {
+			t.Errorf("NewCSVReader(%q) error = %v, want ErrInvalidBallotFile", csv, err)
+		}
+	}
+	cr, err := NewCSVReader(strings.NewReader("Rank 1\nAlice\n\"Bob\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := ReadElection(cr); !errors.Is(err, ErrInvalidBallotFile) {
+		t.Errorf("ReadElection() error = %v, want ErrInvalidBallotFile for an unclosed quote", err)
+	}
+}
+
+func TestJSONReader(t *testing.T) {
+	jr, err := NewJSONReader(strings.NewReader(`{
+		"title": "Board 2024",
+		"candidates": ["Alice", "Bob", "Carol"],
+		"ballots": [
+			{"id": "b1", "ranking": ["Bob", "Alice"]},
+			{"ranking": ["Carol"], "weight": 12},
+			{"id": "b1", "ranking": ["Alice"]},
+			{"ranking": ["Dave"]},
+			{"ranking": ["Alice"], "weight": 0},
+			{"ranking": []}
+		],
+		"seats": 2
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, report, err := ReadElection(jr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(e.Ballots) != 2 || e.Ballots[1].Weight != 12 || e.Seats != 2 || jr.Title != "Board 2024" {
+		t.Errorf("election = %+v, title %q", e, jr.Title)
+	}
+	checkReport(t, report, 2, 1, []string{
+		`ballot 3 "b1": duplicate: ID already seen`,
+		`ballot 4: invalid: unknown candidate "Dave"`,
+		`ballot 5: invalid: weight 0`,
+	})
+}
+
+func TestJSONErrors(t *testing.T) {
+	for _, text := range []string{
+		``,
+		`[]`,
+		`{"candidates": ["A"]}`,
+		`{"ballots": []}`,
+		`{"candidates": "A", "ballots": []}`,
+		`{"candidates": ["A"], "ballots": [{"ranking": "A"}]}`,
+		`{"candidates": ["A"], "ballots": [], "candidates": ["B"]}`,
+		`{"candidates": ["A"], "ballots": [{"ranking": ["A"]}`,
+	} {
+		_, _, err := func() (Election, BallotReport, error) {
+			jr, err := NewJSONReader(strings.NewReader(text))
+			if err != nil {
+				return Election{}, BallotReport{}, err
+			}
+			return ReadElection(jr)
+		}()
+		if !errors.Is(err, ErrInvalidBallotFile) {
+			t.Errorf("reading %s: error = %v, want ErrInvalidBallotFile", text, err)
+		}
+	}
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += n
+	return n, err
+}
+
+func TestTallyLoadStreams(t *testing.T) {
+	const rows = 100000
+	pr, pw := io.Pipe()
+	go func() {
+		fmt.Fprintln(pw, "ID,Rank 1,Rank 2")
+		for i := range rows {
+			fmt.Fprintf(pw, "%d,%s,%s\n", i, []string{"Alice", "Bob", "Carol"}[i%3], "Dave")
+		}
+		// A resubmitted row is not counted twice.
+		fmt.Fprintln(pw, "7,Bob,Alice")
+		pw.Close()
+	}()
+	counted := &countingReader{r: pr}
+	cr, err := NewCSVReader(counted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tally := NewTally()
+	b, err := cr.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tally.IncrementVoteCount(b.Ranking[0], 1)
+	if counted.n > 64<<10 {
+		t.Errorf("read %d bytes for the first ballot, want the file to be streamed", counted.n)
+	}
+	report, err := tally.Load(cr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Valid != rows || report.Count(DuplicateBallot) != 1 {
+		t.Errorf("report = %d valid, %d duplicate", report.Valid, report.Count(DuplicateBallot))
+	}
+	if got := tally.VoteCount("Alice") + tally.VoteCount("Bob") + tally.VoteCount("Carol"); got != rows {
+		t.Errorf("tally counted %d first preferences, want %d", got, rows)
+	}
+	if got := tally.VoteCount("Bob"); got != rows/3 {
+		t.Errorf("VoteCount(Bob) = %d, want %d", got, rows/3)
+	}
+}
+
+func TestTallyLoadBLT(t *testing.T) {
+	tally := NewTally()
+	report, err := tally.LoadBLT(strings.NewReader(sampleBLT))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := displayResults(tally.Results()); !slices.Equal(got, []string{"Alice (3)", "Bob (2)", "Carol (1)"}) {
+		t.Errorf("results = %q", got)
+	}
+	if report.Valid != 3 || report.Exhausted != 1 || len(report.Problems) != 4 {
+		t.Errorf("report = %+v", report)
+	}
+
+	// A large file, counted as it is read.
+	const ballots = 200000
+	pr, pw := io.Pipe()
+	go func() {
+		fmt.Fprintln(pw, "3 1")
+		for i := range ballots {
+			fmt.Fprintf(pw, "1 %d 0\n", i%3+1)
+		}
+		fmt.Fprintln(pw, "0\n\"A\" \"B\" \"C\"")
+		pw.Close()
+	}()
+	tally = NewTally()
+	if _, err := tally.LoadBLT(pr); err != nil {
+		t.Fatal(err)
+	}
+	if got := tally.VoteCount("A") + tally.VoteCount("B") + tally.VoteCount("C"); got != ballots {
+		t.Errorf("counted %d ballots, want %d", got, ballots)
+	}
+
+	// A malformed file adds nothing.
+	tally = NewTally()
+	if _, err := tally.LoadBLT(strings.NewReader("2 1\n1 1 0\n0\n\"A\"\n")); !errors.Is(err, ErrInvalidBallotFile) {
+		t.Errorf("missing names: error = %v", err)
+	}
+	if len(tally.Results()) != 0 {
+		t.Errorf("results after a malformed file = %v", displayResults(tally.Results()))
+	}
+}
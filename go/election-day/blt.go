@@ -0,0 +1,258 @@
+package electionday
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// BLTReader reads the BLT format of OpenSTV and most other STV counting
+// programs:
+//
+//	4 2              number of candidates and seats
+//	-4               optional line of withdrawn candidates
+//	3 1 2 0          a weight, preferences by candidate number, 0
+//	(b7) 1 3 - 2 0   optionally an ID first; "-" skips a preference
+//	0                end of the ballots
+//	"Alice"          the candidate names, in number order
+//	"Bob"
+//	"Carol"
+//	"Dave"
+//	"Board 2024"     optional title
+//
+// Everything after a "#" is a comment. As the names come last, the whole
+// file is read by NewBLTReader, which keeps the ballots by candidate
+// number until it has the names. Tally.LoadBLT counts a file of any size
+// without keeping its ballots.
+type BLTReader struct {
+	ballotLog
+	// Title is the election title, if the file has one.
+	Title      string
+	candidates []string
+	names      []string // by candidate number, withdrawn ones included
+	seats      int
+	ballots    []bltBallot
+}
+
+type bltBallot struct {
+	ranking []int
+	weight  int
+}
+
+// bltLines reads lines without comments and surrounding space, skipping
+// blank ones.
+type bltLines struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func (l *bltLines) next() (string, bool) {
+	for l.scanner.Scan() {
+		l.line++
+		text, _, _ := strings.Cut(l.scanner.Text(), "#")
+		if text = strings.TrimSpace(text); text != "" {
+			return text, true
+		}
+	}
+	return "", false
+}
+
+// bltParser reads a BLT file in one pass: the header, then the ballots as
+// they come, then the names.
+type bltParser struct {
+	lines     *bltLines
+	n, seats  int
+	withdrawn map[int]bool
+	// text is the line read ahead after the header, if ok.
+	text string
+	ok   bool
+}
+
+func (p *bltParser) fail(format string, args ...any) error {
+	if err := p.lines.scanner.Err(); err != nil {
+		return err
+	}
+	return &BallotFileError{Format: "BLT", Line: p.lines.line, Reason: fmt.Sprintf(format, args...)}
+}
+
+// newBLTParser reads the header and the withdrawn candidates.
+func newBLTParser(r io.Reader) (*bltParser, error) {
+	p := &bltParser{lines: &bltLines{scanner: bufio.NewScanner(r)}, withdrawn: make(map[int]bool)}
+	text, _ := p.lines.next()
+	if _, err := fmt.Sscanf(text, "%d %d", &p.n, &p.seats); err != nil || p.n < 1 || p.seats < 1 || p.seats > p.n {
+		return nil, p.fail("header %q is not a number of candidates and seats", text)
+	}
+
+	p.text, p.ok = p.lines.next()
+	if p.ok && strings.HasPrefix(p.text, "-") {
+		for _, field := range strings.Fields(p.text) {
+			c, err := strconv.Atoi(field)
+			if err != nil || c >= 0 || -c > p.n {
+				return nil, p.fail("bad withdrawn candidate %q", field)
+			}
+			p.withdrawn[-c] = true
+		}
+		p.text, p.ok = p.lines.next()
+	}
+	return p, nil
+}
+
+// ballots passes each ballot to be counted to each, recording the others
+// in log, up to the 0 line that ends them.
+func (p *bltParser) ballots(log *ballotLog, each func(bltBallot)) error {
+	for text, ok := p.text, p.ok; ok; text, ok = p.lines.next() {
+		if text == "0" {
+			return nil
+		}
+		log.next()
+		b, id, err := parseBLTBallot(text, p.n)
+		if err != nil {
+			log.invalid(p.lines.line, id, "%v", err)
+			continue
+		}
+		// Withdrawn candidates are skipped as if never ranked.
+		ranking := b.ranking[:0]
+		for _, c := range b.ranking {
+			if !p.withdrawn[c] {
+				ranking = append(ranking, c)
+			}
+		}
+		b.ranking = ranking
+		names := make([]string, len(ranking))
+		for i, c := range ranking {
+			names[i] = strconv.Itoa(c)
+		}
+		if log.accept(Ballot{Ranking: names}, p.lines.line, id) {
+			each(b)
+		}
+	}
+	return p.fail("missing the 0 line ending the ballots")
+}
+
+// names reads the candidate names, by number, and the title.
+func (p *bltParser) names() ([]string, string, error) {
+	var names []string
+	for len(names) < p.n+1 {
+		text, ok := p.lines.next()
+		if !ok {
+			break
+		}
+		for text != "" {
+			name, rest, err := cutBLTString(text)
+			if err != nil {
+				return nil, "", p.fail("%v", err)
+			}
+			names = append(names, name)
+			text = strings.TrimSpace(rest)
+		}
+	}
+	if len(names) < p.n {
+		return nil, "", p.fail("%d candidate names, want %d", len(names), p.n)
+	}
+	if len(names) > p.n+1 {
+		return nil, "", p.fail("unexpected %q after the title", names[p.n+1])
+	}
+	title := ""
+	if len(names) > p.n {
+		title = names[p.n]
+	}
+	return names[:p.n], title, nil
+}
+
+// NewBLTReader reads a whole BLT file. It fails with a *BallotFileError
+// when the header, the end of the ballots or the names are missing or
+// malformed; bad ballots are only reported.
+func NewBLTReader(r io.Reader) (*BLTReader, error) {
+	p, err := newBLTParser(r)
+	if err != nil {
+		return nil, err
+	}
+	br := &BLTReader{seats: p.seats}
+	if err := p.ballots(&br.ballotLog, func(b bltBallot) { br.ballots = append(br.ballots, b) }); err != nil {
+		return nil, err
+	}
+	if br.names, br.Title, err = p.names(); err != nil {
+		return nil, err
+	}
+	for i, name := range br.names {
+		if !p.withdrawn[i+1] {
+			br.candidates = append(br.candidates, name)
+		}
+	}
+	return br, nil
+}
+
+// parseBLTBallot reads "[(id)] weight pref... 0".
+func parseBLTBallot(text string, n int) (bltBallot, string, error) {
+	var id string
+	if strings.HasPrefix(text, "(") {
+		var rest string
+		var ok bool
+		id, rest, ok = strings.Cut(text[1:], ")")
+		if !ok {
+			return bltBallot{}, "", fmt.Errorf("unclosed ballot ID")
+		}
+		text = rest
+	}
+	fields := strings.Fields(text)
+	if len(fields) < 2 || fields[len(fields)-1] != "0" {
+		return bltBallot{}, id, fmt.Errorf("ballot does not end with 0")
+	}
+	weight, err := strconv.Atoi(fields[0])
+	if err != nil || weight < 1 {
+		return bltBallot{}, id, fmt.Errorf("bad weight %q", fields[0])
+	}
+	b := bltBallot{weight: weight}
+	for _, field := range fields[1 : len(fields)-1] {
+		if field == "-" {
+			continue
+		}
+		if strings.Contains(field, "=") {
+			return bltBallot{}, id, fmt.Errorf("equal preferences %q", field)
+		}
+		c, err := strconv.Atoi(field)
+		if err != nil || c < 1 || c > n {
+			return bltBallot{}, id, fmt.Errorf("unknown candidate %q", field)
+		}
+		b.ranking = append(b.ranking, c)
+	}
+	return b, id, nil
+}
+
+// cutBLTString reads a double-quoted string at the start of text.
+func cutBLTString(text string) (string, string, error) {
+	if !strings.HasPrefix(text, `"`) {
+		return "", "", fmt.Errorf("expected a quoted name, got %q", text)
+	}
+	name, rest, ok := strings.Cut(text[1:], `"`)
+	if !ok {
+		return "", "", fmt.Errorf("unclosed quote in %q", text)
+	}
+	return name, rest, nil
+}
+
+// Next implements BallotReader.
+func (br *BLTReader) Next() (Ballot, error) {
+	if len(br.ballots) == 0 {
+		return Ballot{}, io.EOF
+	}
+	b := br.ballots[0]
+	br.ballots = br.ballots[1:]
+	ranking := make([]string, len(b.ranking))
+	for i, c := range b.ranking {
+		ranking[i] = br.names[c-1]
+	}
+	return Ballot{Ranking: ranking, Weight: b.weight}, nil
+}
+
+// Candidates implements BallotReader. Withdrawn candidates are left out.
+func (br *BLTReader) Candidates() []string {
+	return br.candidates
+}
+
+// Seats implements BallotReader.
+func (br *BLTReader) Seats() int {
+	return br.seats
+}
@@ -0,0 +1,43 @@
+// Command electionday-live serves a live count over HTTP. Stations and
+// their HMAC keys are read from a JSON file mapping station IDs to keys:
+//
+//	electionday-live -addr :8080 -keys stations.json
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+
+	"electionday"
+	"electionday/live"
+)
+
+func main() {
+	addr := flag.String("addr", ":8080", "address to listen on")
+	keysFile := flag.String("keys", "stations.json", "JSON file of station IDs and their keys")
+	flag.Parse()
+
+	if err := run(*addr, *keysFile); err != nil {
+		fmt.Fprintln(os.Stderr, "electionday-live:", err)
+		os.Exit(1)
+	}
+}
+
+func run(addr, keysFile string) error {
+	data, err := os.ReadFile(keysFile)
+	if err != nil {
+		return err
+	}
+	var secrets map[string]string
+	if err := json.Unmarshal(data, &secrets); err != nil {
+		return fmt.Errorf("%s: %w", keysFile, err)
+	}
+	keys := make(map[string][]byte, len(secrets))
+	for station, secret := range secrets {
+		keys[station] = []byte(secret)
+	}
+	return http.ListenAndServe(addr, live.NewServer(electionday.NewTally(), keys))
+}
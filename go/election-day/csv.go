@@ -0,0 +1,168 @@
+package electionday
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var (
+	// "Rank the candidates [Alice]", as survey tools name grid columns.
+	csvCandidateColumn = regexp.MustCompile(`\[([^\]]+)\]\s*$`)
+	// "Rank 1", "Choice #2", "Preference 3", "1st choice", "2nd preference".
+	csvPreferenceColumn = regexp.MustCompile(`(?i)^(?:(?:rank|choice|preference)\s*#?\s*(\d+)|(\d+)(?:st|nd|rd|th)\s+(?:choice|preference))$`)
+	csvIDColumns        = []string{"id", "ballot id", "respondent id", "response id", "responseid"}
+)
+
+// CSVReader reads ranked ballots exported as CSV by survey tools, one
+// ballot per row after a header row. The header decides the layout:
+//
+//   - Grid layout: columns named like "Rank the candidates [Alice]" hold
+//     the rank given to that candidate, 1 being the first preference,
+//     and a blank cell for an unranked candidate.
+//   - Preference layout: columns named like "Rank 1" or "1st choice"
+//     hold the name of the candidate given that preference.
+//
+// A column named "ID", "Respondent ID" or "Response ID" is used to find
+// duplicate ballots, and every other column, e.g. a timestamp, is ignored.
+// Rows are read as Next is called, so files of any size can be streamed.
+type CSVReader struct {
+	ballotLog
+	r          *csv.Reader
+	id         int // -1 without an ID column
+	candidates []string
+	// grid maps columns to candidates in the grid layout, preference
+	// maps columns to 0-based preferences in the other one.
+	grid       map[int]string
+	preference map[int]int
+}
+
+// NewCSVReader reads the header of a CSV file. It fails with a
+// *BallotFileError when no column holds candidates or preferences.
+func NewCSVReader(r io.Reader) (*CSVReader, error) {
+	cr := &CSVReader{r: csv.NewReader(r), id: -1, grid: make(map[int]string), preference: make(map[int]int)}
+	cr.r.FieldsPerRecord = -1
+	cr.r.TrimLeadingSpace = true
+	header, err := cr.r.Read()
+	if err == io.EOF {
+		return nil, &BallotFileError{Format: "CSV", Line: 1, Reason: "no header row"}
+	}
+	if err != nil {
+		return nil, csvError(err)
+	}
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		if m := csvCandidateColumn.FindStringSubmatch(name); m != nil {
+			candidate := strings.TrimSpace(m[1])
+			if slices.Contains(cr.candidates, candidate) {
+				return nil, &BallotFileError{Format: "CSV", Line: 1, Reason: fmt.Sprintf("two columns for %q", candidate)}
+			}
+			cr.grid[i] = candidate
+			cr.candidates = append(cr.candidates, candidate)
+		} else if m := csvPreferenceColumn.FindStringSubmatch(name); m != nil {
+			n, _ := strconv.Atoi(m[1] + m[2])
+			cr.preference[i] = n - 1
+		} else if slices.Contains(csvIDColumns, strings.ToLower(name)) {
+			cr.id = i
+		}
+	}
+	switch {
+	case len(cr.grid) > 0 && len(cr.preference) > 0:
+		return nil, &BallotFileError{Format: "CSV", Line: 1, Reason: "both candidate and preference columns"}
+	case len(cr.grid) == 0 && len(cr.preference) == 0:
+		return nil, &BallotFileError{Format: "CSV", Line: 1, Reason: "no candidate or preference columns"}
+	}
+	return cr, nil
+}
+
+func csvError(err error) error {
+	if parseErr, ok := err.(*csv.ParseError); ok {
+		return &BallotFileError{Format: "CSV", Line: parseErr.Line, Reason: parseErr.Err.Error()}
+	}
+	return err
+}
+
+// Next implements BallotReader.
+func (cr *CSVReader) Next() (Ballot, error) {
+	for {
+		row, err := cr.r.Read()
+		if err != nil {
+			return Ballot{}, csvError(err)
+		}
+		line, _ := cr.r.FieldPos(0)
+		cr.next()
+		id := ""
+		if cr.id >= 0 && cr.id < len(row) {
+			id = strings.TrimSpace(row[cr.id])
+		}
+		ranking, err := cr.ranking(row)
+		if err != nil {
+			cr.invalid(line, id, "%v", err)
+			continue
+		}
+		b := Ballot{Ranking: ranking}
+		if cr.accept(b, line, id) {
+			for _, c := range ranking {
+				if !slices.Contains(cr.candidates, c) {
+					cr.candidates = append(cr.candidates, c)
+				}
+			}
+			return b, nil
+		}
+	}
+}
+
+// ranking reads the preferences of a row in either layout.
+func (cr *CSVReader) ranking(row []string) ([]string, error) {
+	byRank := make(map[int]string)
+	if len(cr.grid) > 0 {
+		for _, i := range slices.Sorted(maps.Keys(cr.grid)) {
+			candidate := cr.grid[i]
+			cell := ""
+			if i < len(row) {
+				cell = strings.TrimSpace(row[i])
+			}
+			if cell == "" {
+				continue
+			}
+			rank, err := strconv.Atoi(cell)
+			if err != nil || rank < 1 {
+				return nil, fmt.Errorf("bad rank %q for %q", cell, candidate)
+			}
+			if other, ok := byRank[rank]; ok {
+				return nil, fmt.Errorf("%q and %q both ranked %d", other, candidate, rank)
+			}
+			byRank[rank] = candidate
+		}
+	} else {
+		for i, rank := range cr.preference {
+			if i < len(row) {
+				if cell := strings.TrimSpace(row[i]); cell != "" {
+					byRank[rank] = cell
+				}
+			}
+		}
+	}
+	// Gaps in the ranks, e.g. a skipped second choice, close up.
+	var ranking []string
+	for _, rank := range slices.Sorted(maps.Keys(byRank)) {
+		ranking = append(ranking, byRank[rank])
+	}
+	return ranking, nil
+}
+
+// Candidates implements BallotReader. In the preference layout candidates
+// are listed as they are first seen in the file.
+func (cr *CSVReader) Candidates() []string {
+	return cr.candidates
+}
+
+// Seats implements BallotReader; CSV exports do not say.
+func (cr *CSVReader) Seats() int {
+	return 0
+}
@@ -0,0 +1,333 @@
+package electionday
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+var (
+	// ErrUnknownArea is returned when reporting results for an area that
+	// is not in the hierarchy, or is not a precinct.
+	ErrUnknownArea = errors.New("unknown precinct")
+	// ErrCannotAllocate is returned for a negative number of seats, or
+	// seats to allocate without any votes.
+	ErrCannotAllocate = errors.New("cannot allocate seats")
+)
+
+// SeatResult is the number of seats a candidate or party won.
+type SeatResult struct {
+	Name  string
+	Votes int
+	Seats int
+}
+
+// A SeatMethod allocates seats according to the results. Ties for a seat
+// go to the candidate with more votes, and then to the name first in
+// alphabetical order. The results come back with most seats first, then
+// most votes, then by name.
+type SeatMethod func(results []*ElectionResult, seats int) ([]SeatResult, error)
+
+// seatResults adds up the results and checks they can be allocated.
+func seatResults(results []*ElectionResult, seats int) ([]SeatResult, int, error) {
+	votes := make(map[string]int)
+	total := 0
+	for _, r := range results {
+		votes[r.Name] += r.Votes
+		total += r.Votes
+	}
+	if seats < 0 || (seats > 0 && total <= 0) {
+		return nil, 0, fmt.Errorf("%w: %d seats for %d votes", ErrCannotAllocate, seats, total)
+	}
+	var out []SeatResult
+	for _, r := range sortedResults(votes) {
+		out = append(out, SeatResult{Name: r.Name, Votes: r.Votes})
+	}
+	return out, total, nil
+}
+
+func sortSeats(seats []SeatResult) {
+	slices.SortStableFunc(seats, func(a, b SeatResult) int {
+		return cmp.Or(cmp.Compare(b.Seats, a.Seats), cmp.Compare(b.Votes, a.Votes), cmp.Compare(a.Name, b.Name))
+	})
+}
+
+// highestAverages gives seats one at a time to the largest quotient of
+// votes / divisor(seats won so far).
+func highestAverages(results []*ElectionResult, seats int, divisor func(won int) int) ([]SeatResult, error) {
+	out, _, err := seatResults(results, seats)
+	if err != nil {
+		return nil, err
+	}
+	for range seats {
+		best := 0
+		for i := 1; i < len(out); i++ {
+			// out[i].Votes / d(i) > out[best].Votes / d(best), exactly.
+			if out[i].Votes*divisor(out[best].Seats) > out[best].Votes*divisor(out[i].Seats) {
+				best = i
+			}
+		}
+		out[best].Seats++
+	}
+	sortSeats(out)
+	return out, nil
+}
+
+// DHondt allocates seats by the highest averages with divisors 1, 2, 3...,
+// which favours larger parties.
+func DHondt(results []*ElectionResult, seats int) ([]SeatResult, error) {
+	return highestAverages(results, seats, func(won int) int { return won + 1 })
+}
+
+// SainteLague allocates seats by the highest averages with divisors 1, 3,
+// 5..., which is closer to proportional than DHondt for small parties.
+func SainteLague(results []*ElectionResult, seats int) ([]SeatResult, error) {
+	return highestAverages(results, seats, func(won int) int { return 2*won + 1 })
+}
+
+// HareNiemeyer gives each party the whole part of its share of the seats,
+// votes * seats / total, and the seats left to the largest remainders.
+func HareNiemeyer(results []*ElectionResult, seats int) ([]SeatResult, error) {
+	out, total, err := seatResults(results, seats)
+	if err != nil || seats == 0 {
+		return out, err
+	}
+	left := seats
+	for i := range out {
+		out[i].Seats = out[i].Votes * seats / total
+		left -= out[i].Seats
+	}
+	// out is sorted by votes, so a stable sort breaks ties the same way.
+	byRemainder := make([]int, len(out))
+	for i := range byRemainder {
+		byRemainder[i] = i
+	}
+	slices.SortStableFunc(byRemainder, func(a, b int) int {
+		return cmp.Compare(out[b].Votes*seats%total, out[a].Votes*seats%total)
+	})
+	for _, i := range byRemainder[:left] {
+		out[i].Seats++
+	}
+	sortSeats(out)
+	return out, nil
+}
+
+// WinnerTakeAll gives every seat to the candidate with most votes.
+func WinnerTakeAll(results []*ElectionResult, seats int) ([]SeatResult, error) {
+	out, _, err := seatResults(results, seats)
+	if err != nil || len(out) == 0 {
+		return out, err
+	}
+	out[0].Seats = seats
+	return out, nil
+}
+
+// Area is a node of a reporting hierarchy, e.g. a region made of
+// districts made of precincts. Areas without children are precincts,
+// and only precincts have results of their own.
+type Area struct {
+	Name string
+	// Level names the kind of area, e.g. "precinct" or "district".
+	Level    string
+	Children []*Area
+	// Electorate is the number of registered voters of a precinct, which
+	// bounds the votes still to come while it has not reported.
+	Electorate int
+	// Results of a precinct, nil until it reports.
+	Results []*ElectionResult
+	// Seats are elected from this area's totals by Method, or by
+	// WinnerTakeAll if Method is nil.
+	Seats  int
+	Method SeatMethod
+}
+
+// Find returns the area with the given name in the hierarchy, or nil.
+func (a *Area) Find(name string) *Area {
+	if a.Name == name {
+		return a
+	}
+	for _, child := range a.Children {
+		if found := child.Find(name); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// ReportResults records the results of a precinct, replacing any it had.
+func (a *Area) ReportResults(precinct string, results []*ElectionResult) error {
+	p := a.Find(precinct)
+	if p == nil || len(p.Children) > 0 {
+		return fmt.Errorf("%w: %q", ErrUnknownArea, precinct)
+	}
+	p.Results = slices.Clone(results)
+	return nil
+}
+
+// AreaCount is the count of an area, added up from its precincts.
+type AreaCount struct {
+	Name, Level string
+	// Results are the totals of the precincts that reported, most votes
+	// first.
+	Results    []*ElectionResult
+	TotalVotes int
+	// Precincts is the number of precincts in the area and Reporting the
+	// number that reported.
+	Precincts, Reporting int
+	// Outstanding is the number of registered voters in precincts that
+	// have not reported.
+	Outstanding int
+	// Seats are the seats allocated in this area and the areas below,
+	// added up; nil if none are.
+	Seats []SeatResult
+	// Provisional is set when outstanding precincts could still change
+	// the seats, or the leader of an area without seats.
+	Provisional bool
+	Children    []*AreaCount
+
+	// unbounded is set when a precinct that has not reported does not
+	// give its electorate, so the votes to come are unknown.
+	unbounded bool
+}
+
+// PercentReporting returns the share of precincts that reported, e.g. 62
+// for "62% of precincts in".
+func (c *AreaCount) PercentReporting() float64 {
+	return percent(c.Reporting, c.Precincts)
+}
+
+// Count adds up the results bottom-up and allocates the seats of every
+// area with seats. With precincts still out, an allocation is final only
+// if no candidate, including one not seen in any result yet, could change
+// it by winning every outstanding vote. That is exact for WinnerTakeAll and
+// the highest averages methods, where more votes never cost a seat.
+func (a *Area) Count() (*AreaCount, error) {
+	c := &AreaCount{Name: a.Name, Level: a.Level}
+	votes := make(map[string]int)
+	seats := make(map[string]int)
+	provisionalBelow := false
+
+	if len(a.Children) == 0 {
+		c.Precincts = 1
+		if a.Results != nil {
+			c.Reporting = 1
+			for _, r := range a.Results {
+				votes[r.Name] += r.Votes
+			}
+		} else {
+			c.Outstanding = a.Electorate
+			c.unbounded = a.Electorate <= 0
+		}
+	}
+	for _, child := range a.Children {
+		cc, err := child.Count()
+		if err != nil {
+			return nil, err
+		}
+		c.Children = append(c.Children, cc)
+		c.Precincts += cc.Precincts
+		c.Reporting += cc.Reporting
+		c.Outstanding += cc.Outstanding
+		c.unbounded = c.unbounded || cc.unbounded
+		for _, r := range cc.Results {
+			votes[r.Name] += r.Votes
+		}
+		if cc.Seats != nil {
+			provisionalBelow = provisionalBelow || cc.Provisional
+			for _, s := range cc.Seats {
+				seats[s.Name] += s.Seats
+			}
+		}
+	}
+	c.Results = sortedResults(votes)
+	for _, r := range c.Results {
+		c.TotalVotes += r.Votes
+	}
+
+	switch {
+	case a.Seats > 0 || len(seats) == 0:
+		// Areas without seats anywhere in them still have a leader,
+		// which is what Provisional is about for them.
+		n := max(a.Seats, 1)
+		if c.TotalVotes == 0 {
+			c.Provisional = c.Reporting < c.Precincts
+			break
+		}
+		method := a.Method
+		if method == nil {
+			method = WinnerTakeAll
+		}
+		allocation, err := method(c.Results, n)
+		if err != nil {
+			return nil, fmt.Errorf("allocating the seats of %s: %w", a.Name, err)
+		}
+		final, err := c.final(method, n, allocation)
+		if err != nil {
+			return nil, err
+		}
+		c.Provisional = provisionalBelow || !final
+		if a.Seats > 0 {
+			for _, s := range allocation {
+				seats[s.Name] += s.Seats
+			}
+		}
+	default:
+		c.Provisional = provisionalBelow
+	}
+
+	if len(seats) > 0 {
+		for name, n := range seats {
+			c.Seats = append(c.Seats, SeatResult{Name: name, Votes: votes[name], Seats: n})
+		}
+		sortSeats(c.Seats)
+	}
+	return c, nil
+}
+
+// final reports whether an allocation stands however the outstanding
+// votes are cast, trying each candidate winning all of them. A candidate
+// only on the ballots still to come is tried as an extra one with no
+// votes so far.
+func (c *AreaCount) final(method SeatMethod, seats int, allocation []SeatResult) (bool, error) {
+	if c.unbounded {
+		return false, nil
+	}
+	if c.Outstanding == 0 {
+		return true, nil
+	}
+	want := seatMap(allocation)
+	unseen := "unseen"
+	for slices.ContainsFunc(c.Results, func(r *ElectionResult) bool { return r.Name == unseen }) {
+		unseen += "'"
+	}
+	for i := range len(c.Results) + 1 {
+		what := make([]*ElectionResult, len(c.Results), len(c.Results)+1)
+		for j, r := range c.Results {
+			what[j] = NewElectionResult(r.Name, r.Votes)
+		}
+		if i == len(c.Results) {
+			what = append(what, NewElectionResult(unseen, 0))
+		}
+		what[i].Votes += c.Outstanding
+		got, err := method(what, seats)
+		if err != nil {
+			return false, err
+		}
+		if !maps.Equal(seatMap(got), want) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func seatMap(results []SeatResult) map[string]int {
+	m := make(map[string]int, len(results))
+	for _, s := range results {
+		if s.Seats > 0 {
+			m[s.Name] = s.Seats
+		}
+	}
+	return m
+}
@@ -0,0 +1,200 @@
+package electionday
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func seatString(seats []SeatResult) string {
+	s := ""
+	for _, r := range seats {
+		if r.Seats > 0 {
+			s += fmt.Sprintf("%s%d ", r.Name, r.Seats)
+		}
+	}
+	return s
+}
+
+func TestSeatMethods(t *testing.T) {
+	parties := results("A", 100000, "B", 80000, "C", 30000, "D", 20000)
+	tests := []struct {
+		name   string
+		method SeatMethod
+		votes  []*ElectionResult
+		seats  int
+		want   string
+	}{
+		{name: "D'Hondt", method: DHondt, votes: parties, seats: 8, want: "A4 B3 C1 "},
+		{name: "Sainte-Laguë", method: SainteLague, votes: parties, seats: 8, want: "A3 B3 C1 D1 "},
+		{name: "Hare-Niemeyer", method: HareNiemeyer, votes: parties, seats: 8, want: "A3 B3 C1 D1 "},
+		{name: "winner-take-all", method: WinnerTakeAll, votes: parties, seats: 8, want: "A8 "},
+		{name: "no seats", method: HareNiemeyer, votes: parties, seats: 0, want: ""},
+		{
+			// Equal quotients go to the party with more votes: A's 200/2
+			// against B's 100/1.
+			name: "D'Hondt tie", method: DHondt, votes: results("B", 100, "A", 200), seats: 2, want: "A2 ",
+		},
+		{
+			name: "Hare-Niemeyer tie", method: HareNiemeyer, votes: results("B", 10, "A", 10, "C", 10), seats: 2, want: "A1 B1 ",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.method(tt.votes, tt.seats)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s := seatString(got); s != tt.want {
+				t.Errorf("seats = %q, want %q", s, tt.want)
+			}
+			if len(got) != len(tt.votes) {
+				t.Errorf("%d results, want one per party", len(got))
+			}
+		})
+	}
+
+	for _, method := range []SeatMethod{DHondt, SainteLague, HareNiemeyer, WinnerTakeAll} {
+		if _, err := method(parties, -1); !errors.Is(err, ErrCannotAllocate) {
+			t.Errorf("allocating -1 seats: error = %v, want ErrCannotAllocate", err)
+		}
+		if _, err := method(results("A", 0), 3); !errors.Is(err, ErrCannotAllocate) {
+			t.Errorf("allocating without votes: error = %v, want ErrCannotAllocate", err)
+		}
+	}
+}
+
+// college builds a small electoral college: three winner-take-all states
+// and one that splits its electors by D'Hondt.
+func college() *Area {
+	precinct := func(name string, electorate int) *Area {
+		return &Area{Name: name, Level: "precinct", Electorate: electorate}
+	}
+	state := func(name string, seats int, precincts ...*Area) *Area {
+		return &Area{Name: name, Level: "state", Seats: seats, Children: precincts}
+	}
+	north := state("North", 5, precinct("N1", 1000), precinct("N2", 1000))
+	south := state("South", 3, precinct("S1", 1000), precinct("S2", 500))
+	east := state("East", 4, precinct("E1", 1000))
+	west := state("West", 4, precinct("W1", 2000), precinct("W2", 0))
+	west.Method = DHondt
+	return &Area{Name: "Nation", Level: "nation", Children: []*Area{
+		{Name: "Coast", Level: "region", Children: []*Area{north, south}},
+		{Name: "Inland", Level: "region", Children: []*Area{east, west}},
+	}}
+}
+
+func TestAreaCount(t *testing.T) {
+	nation := college()
+	for _, r := range []struct {
+		precinct string
+		votes    []*ElectionResult
+	}{
+		{"N1", results("Red", 600, "Blue", 300)},
+		{"N2", results("Red", 500, "Blue", 450)},
+		{"S1", results("Red", 400, "Blue", 500)},
+		{"E1", results("Blue", 700, "Red", 200)},
+		{"W1", results("Red", 900, "Blue", 800, "Green", 100)},
+	} {
+		if err := nation.ReportResults(r.precinct, r.votes); err != nil {
+			t.Fatal(err)
+		}
+	}
+	count, err := nation.Count()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if count.Precincts != 7 || count.Reporting != 5 || count.PercentReporting() != 71.43 || count.Outstanding != 500 {
+		t.Errorf("nation: %d of %d precincts (%v%%), %d outstanding",
+			count.Reporting, count.Precincts, count.PercentReporting(), count.Outstanding)
+	}
+	if got := displayResults(count.Results); !slices.Equal(got, []string{"Blue (2750)", "Red (2600)", "Green (100)"}) {
+		t.Errorf("nation results = %q", got)
+	}
+	if got := seatString(count.Seats); got != "Blue9 Red7 " {
+		t.Errorf("nation seats = %q, want Blue9 Red7", got)
+	}
+
+	coast, inland := count.Children[0], count.Children[1]
+	north, south := coast.Children[0], coast.Children[1]
+	east, west := inland.Children[0], inland.Children[1]
+	// North is fully in. South trails by 100 with 500 voters to come.
+	// West misses a precinct without a known electorate.
+	for _, tt := range []struct {
+		c           *AreaCount
+		seats       string
+		provisional bool
+	}{
+		{north, "Red5 ", false},
+		{south, "Blue3 ", true},
+		{east, "Blue4 ", false},
+		{west, "Red2 Blue2 ", true},
+		{coast, "Red5 Blue3 ", true},
+		{count, "Blue9 Red7 ", true},
+	} {
+		if got := seatString(tt.c.Seats); got != tt.seats || tt.c.Provisional != tt.provisional {
+			t.Errorf("%s: seats %q provisional %v, want %q %v", tt.c.Name, got, tt.c.Provisional, tt.seats, tt.provisional)
+		}
+	}
+
+	// South's last precinct cannot overturn a lead of more than its
+	// electorate.
+	nation.ReportResults("S1", results("Red", 400, "Blue", 901))
+	if count, _ := nation.Count(); count.Children[0].Children[1].Provisional {
+		t.Errorf("South provisional with a lead of 501 and 500 voters to come")
+	}
+	nation.ReportResults("S2", results("Red", 450, "Blue", 20))
+	nation.ReportResults("W2", results("Green", 10))
+	count, err = nation.Count()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count.Provisional || count.PercentReporting() != 100 {
+		t.Errorf("fully reported count provisional %v, %v%% reporting", count.Provisional, count.PercentReporting())
+	}
+}
+
+func TestAreaErrors(t *testing.T) {
+	nation := college()
+	if err := nation.ReportResults("Nowhere", results("Red", 1)); !errors.Is(err, ErrUnknownArea) {
+		t.Errorf("reporting an unknown precinct: error = %v", err)
+	}
+	if err := nation.ReportResults("North", results("Red", 1)); !errors.Is(err, ErrUnknownArea) {
+		t.Errorf("reporting a state as a precinct: error = %v", err)
+	}
+	count, err := nation.Count()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count.Reporting != 0 || count.Seats != nil || !count.Provisional {
+		t.Errorf("count before any results = %+v", count)
+	}
+}
+
+// A candidate on none of the reported ballots can still take a seat.
+func TestAreaCountLateCandidate(t *testing.T) {
+	state := &Area{Name: "Lake", Level: "state", Seats: 3, Method: DHondt, Children: []*Area{
+		{Name: "L1", Level: "precinct", Electorate: 1200},
+		{Name: "L2", Level: "precinct", Electorate: 400},
+	}}
+	state.ReportResults("L1", results("Red", 900))
+	count, err := state.Count()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Red's third quotient is 300, and 400 votes are still out.
+	if got := seatString(count.Seats); got != "Red3 " || !count.Provisional {
+		t.Errorf("seats %q provisional %v, want Red3 provisional", got, count.Provisional)
+	}
+
+	state.Children[1].Electorate = 250
+	if count, _ := state.Count(); count.Provisional {
+		t.Error("provisional with 250 votes out against a quotient of 300")
+	}
+	state.ReportResults("L2", results("Green", 400))
+	if count, _ := state.Count(); seatString(count.Seats) != "Red2 Green1 " {
+		t.Errorf("seats after L2 = %q, want Red2 Green1", seatString(count.Seats))
+	}
+}
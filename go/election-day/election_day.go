@@ -16,7 +16,9 @@ func VoteCount(counter *int) int {
 	return *counter
 }
 
-// IncrementVoteCount increments the value in a vote counter.
+// IncrementVoteCount increments the value in a vote counter. It is not
+// safe for concurrent use; stations counting in parallel should share a
+// Tally instead.
 func IncrementVoteCount(counter *int, increment int) {
 	*counter += increment
 }
@@ -0,0 +1,194 @@
+package electionday
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+)
+
+// JSONReader reads ballots in this JSON schema:
+//
+//	{
+//	  "title": "Board 2024",
+//	  "seats": 2,
+//	  "candidates": ["Alice", "Bob", "Carol"],
+//	  "ballots": [
+//	    {"id": "b1", "ranking": ["Bob", "Alice"]},
+//	    {"ranking": ["Carol"], "weight": 12}
+//	  ]
+//	}
+//
+// Only "candidates" and "ballots" are required, and "candidates" must come
+// before "ballots" so the ballots can be checked as they stream in. A
+// ballot's "id" is used to find duplicates and "weight" defaults to 1.
+type JSONReader struct {
+	ballotLog
+	// Title is the election title, if the file has one.
+	Title      string
+	dec        *json.Decoder
+	candidates []string
+	seats      int
+	done       bool
+}
+
+type jsonBallot struct {
+	ID      string   `json:"id"`
+	Ranking []string `json:"ranking"`
+	Weight  *int     `json:"weight"`
+}
+
+// NewJSONReader reads up to the start of the ballots. It fails with a
+// *BallotFileError when the file does not follow the schema.
+func NewJSONReader(r io.Reader) (*JSONReader, error) {
+	jr := &JSONReader{dec: json.NewDecoder(r)}
+	if err := jr.expect(json.Delim('{')); err != nil {
+		return nil, err
+	}
+	for {
+		key, err := jr.key()
+		if err != nil {
+			return nil, err
+		}
+		switch key {
+		case "":
+			return nil, jr.fail(`no "ballots"`)
+		case "ballots":
+			if jr.candidates == nil {
+				return nil, jr.fail(`"candidates" must come before "ballots"`)
+			}
+			if err := jr.expect(json.Delim('[')); err != nil {
+				return nil, err
+			}
+			return jr, nil
+		default:
+			if err := jr.field(key); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+func (jr *JSONReader) fail(format string, args ...any) error {
+	return &BallotFileError{Format: "JSON", Reason: fmt.Sprintf(format, args...)}
+}
+
+func (jr *JSONReader) syntax(err error) error {
+	if err == io.EOF {
+		return jr.fail("unexpected end of file")
+	}
+	return jr.fail("%v", err)
+}
+
+func (jr *JSONReader) expect(want json.Delim) error {
+	tok, err := jr.dec.Token()
+	if err != nil {
+		return jr.syntax(err)
+	}
+	if tok != want {
+		return jr.fail("expected %v, got %v", want, tok)
+	}
+	return nil
+}
+
+// key returns the next key of the top-level object, or "" at its end.
+func (jr *JSONReader) key() (string, error) {
+	tok, err := jr.dec.Token()
+	if err != nil {
+		return "", jr.syntax(err)
+	}
+	if tok == json.Delim('}') {
+		return "", nil
+	}
+	return tok.(string), nil
+}
+
+// field decodes a top-level field other than "ballots".
+func (jr *JSONReader) field(key string) error {
+	var err error
+	switch key {
+	case "title":
+		err = jr.dec.Decode(&jr.Title)
+	case "seats":
+		err = jr.dec.Decode(&jr.seats)
+	case "candidates":
+		if jr.candidates != nil {
+			return jr.fail(`"candidates" given twice`)
+		}
+		jr.candidates = []string{}
+		err = jr.dec.Decode(&jr.candidates)
+	default:
+		var skip json.RawMessage
+		err = jr.dec.Decode(&skip)
+	}
+	if err != nil {
+		return jr.fail("%s: %v", key, err)
+	}
+	return nil
+}
+
+// Next implements BallotReader.
+func (jr *JSONReader) Next() (Ballot, error) {
+	for !jr.done {
+		if !jr.dec.More() {
+			if err := jr.expect(json.Delim(']')); err != nil {
+				return Ballot{}, err
+			}
+			if err := jr.finish(); err != nil {
+				return Ballot{}, err
+			}
+			break
+		}
+		var jb jsonBallot
+		if err := jr.dec.Decode(&jb); err != nil {
+			return Ballot{}, jr.syntax(err)
+		}
+		jr.next()
+		b := Ballot{Ranking: jb.Ranking, Weight: 1}
+		if jb.Weight != nil {
+			if *jb.Weight < 1 {
+				jr.invalid(0, jb.ID, "weight %d", *jb.Weight)
+				continue
+			}
+			b.Weight = *jb.Weight
+		}
+		if i := slices.IndexFunc(b.Ranking, func(c string) bool { return !slices.Contains(jr.candidates, c) }); i >= 0 {
+			jr.invalid(0, jb.ID, "unknown candidate %q", b.Ranking[i])
+			continue
+		}
+		if jr.accept(b, 0, jb.ID) {
+			return b, nil
+		}
+	}
+	return Ballot{}, io.EOF
+}
+
+// finish reads the fields after "ballots".
+func (jr *JSONReader) finish() error {
+	jr.done = true
+	for {
+		key, err := jr.key()
+		if err != nil {
+			return err
+		}
+		switch key {
+		case "":
+			return nil
+		case "candidates", "ballots":
+			return jr.fail("%q after the ballots", key)
+		}
+		if err := jr.field(key); err != nil {
+			return err
+		}
+	}
+}
+
+// Candidates implements BallotReader.
+func (jr *JSONReader) Candidates() []string {
+	return jr.candidates
+}
+
+// Seats implements BallotReader.
+func (jr *JSONReader) Seats() int {
+	return jr.seats
+}
@@ -0,0 +1,259 @@
+// Package live serves an election count in progress over HTTP: polling
+// stations submit signed batches, and anyone can read the standings or
+// follow them as server-sent events.
+//
+// The endpoints are:
+//
+//	POST /batches   submit {"station": "north-1", "votes": {"Mary": 12}}
+//	GET  /results   the current standings
+//	GET  /events    a text/event-stream of standings, sent on every change
+//
+// A batch must carry the headers X-Timestamp, the Unix time in seconds,
+// and X-Signature, "sha256=" and the hex HMAC-SHA256 of the timestamp, a
+// ".", and the body, keyed with the station's secret. See Sign.
+package live
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"electionday"
+)
+
+// DefaultMaxSkew is how far a batch's timestamp may be from the server's
+// clock, which limits how long a captured request can be replayed.
+const DefaultMaxSkew = 5 * time.Minute
+
+// maxBatchSize bounds the body of a batch.
+const maxBatchSize = 1 << 20
+
+// Server is an http.Handler for a tally. It is safe for concurrent use.
+type Server struct {
+	// MaxSkew is the largest accepted difference between a batch's
+	// timestamp and the clock.
+	MaxSkew time.Duration
+
+	tally *electionday.Tally
+	keys  map[string][]byte
+	mux   *http.ServeMux
+	now   func() time.Time
+
+	mu          sync.Mutex
+	subscribers map[chan Standings]bool
+}
+
+// NewServer returns a server for the tally, accepting batches from the
+// stations in keys signed with their key.
+func NewServer(tally *electionday.Tally, keys map[string][]byte) *Server {
+	s := &Server{
+		MaxSkew:     DefaultMaxSkew,
+		tally:       tally,
+		keys:        keys,
+		mux:         http.NewServeMux(),
+		now:         time.Now,
+		subscribers: make(map[chan Standings]bool),
+	}
+	s.mux.HandleFunc("POST /batches", s.submit)
+	s.mux.HandleFunc("GET /results", s.results)
+	s.mux.HandleFunc("GET /events", s.events)
+	return s
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Sign returns the X-Signature header value for a batch body sent at the
+// given time.
+func Sign(key []byte, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, key)
+	fmt.Fprintf(mac, "%d.", timestamp.Unix())
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Batch is the body of a batch submission.
+type Batch struct {
+	Station string         `json:"station"`
+	Votes   map[string]int `json:"votes"`
+}
+
+// Result is a candidate's line in the standings.
+type Result struct {
+	Name  string `json:"name"`
+	Votes int    `json:"votes"`
+}
+
+// Standings are what GET /results returns and /events sends.
+type Standings struct {
+	Results []Result `json:"results"`
+	// Stations is the number of stations that submitted a batch.
+	Stations int `json:"stations"`
+}
+
+func (s *Server) standings() Standings {
+	st := Standings{Results: []Result{}}
+	for _, r := range s.tally.Results() {
+		st.Results = append(st.Results, Result{Name: r.Name, Votes: r.Votes})
+	}
+	st.Stations = len(s.tally.Stations())
+	return st
+}
+
+// writeJSON writes v as a JSON response with the given status.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, format string, args ...any) {
+	writeJSON(w, status, map[string]string{"error": fmt.Sprintf(format, args...)})
+}
+
+// submit handles POST /batches. It answers 201 for a new batch, 200 for a
+// batch already submitted, 401 for a bad signature, 400 for an invalid
+// batch, 409 for a station changing its batch and 413 for a body over
+// maxBatchSize.
+func (s *Server) submit(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBatchSize))
+	if err != nil {
+		status := http.StatusBadRequest
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		writeError(w, status, "reading the batch: %v", err)
+		return
+	}
+	var batch Batch
+	if err := json.Unmarshal(body, &batch); err != nil {
+		writeError(w, http.StatusBadRequest, "decoding the batch: %v", err)
+		return
+	}
+	if err := s.authenticate(r, batch.Station, body); err != nil {
+		writeError(w, http.StatusUnauthorized, "%v", err)
+		return
+	}
+
+	applied, err := s.tally.SubmitBatch(batch.Station, batch.Votes)
+	switch {
+	case errors.Is(err, electionday.ErrConflictingBatch):
+		writeError(w, http.StatusConflict, "%v", err)
+	case err != nil:
+		writeError(w, http.StatusBadRequest, "%v", err)
+	case applied:
+		writeJSON(w, http.StatusCreated, s.broadcast())
+	default:
+		writeJSON(w, http.StatusOK, s.standings())
+	}
+}
+
+// authenticate checks the timestamp and signature of a batch.
+func (s *Server) authenticate(r *http.Request, station string, body []byte) error {
+	key, ok := s.keys[station]
+	if !ok {
+		return fmt.Errorf("unknown station %q", station)
+	}
+	sec, err := strconv.ParseInt(r.Header.Get("X-Timestamp"), 10, 64)
+	if err != nil {
+		return fmt.Errorf("missing or bad X-Timestamp")
+	}
+	timestamp := time.Unix(sec, 0)
+	if skew := s.now().Sub(timestamp).Abs(); skew > s.MaxSkew {
+		return fmt.Errorf("timestamp is %v off", skew.Round(time.Second))
+	}
+	if !hmac.Equal([]byte(r.Header.Get("X-Signature")), []byte(Sign(key, timestamp, body))) {
+		return fmt.Errorf("bad signature")
+	}
+	return nil
+}
+
+// results handles GET /results.
+func (s *Server) results(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.standings())
+}
+
+// broadcast sends the current standings to every subscriber and returns
+// them. Taking them under the lock keeps subscribers from seeing older
+// standings after newer ones. A subscriber that has not taken the
+// previous standings yet gets these instead.
+func (s *Server) broadcast() Standings {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := s.standings()
+	for ch := range s.subscribers {
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- st
+	}
+	return st
+}
+
+// subscribe returns a channel of standings to come and the standings as
+// they are, taken together so that none are missed or seen out of order.
+func (s *Server) subscribe() (chan Standings, Standings) {
+	ch := make(chan Standings, 1)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subscribers[ch] = true
+	return ch, s.standings()
+}
+
+func (s *Server) unsubscribe(ch chan Standings) {
+	s.mu.Lock()
+	delete(s.subscribers, ch)
+	s.mu.Unlock()
+}
+
+// events handles GET /events, sending the standings as they are and then
+// on every change until the client goes away.
+func (s *Server) events(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming is not supported")
+		return
+	}
+	ch, current := s.subscribe()
+	defer s.unsubscribe(ch)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	send := func(st Standings) error {
+		data, err := json.Marshal(st)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "event: results\ndata: %s\n\n", data); err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	}
+	if send(current) != nil {
+		return
+	}
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case st := <-ch:
+			if send(st) != nil {
+				return
+			}
+		}
+	}
+}
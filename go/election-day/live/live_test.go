@@ -0,0 +1,269 @@
+package live
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"electionday"
+)
+
+var keys = map[string][]byte{
+	"north-1": []byte("north secret"),
+	"south-1": []byte("south secret"),
+}
+
+func newTestServer(t *testing.T) (*httptest.Server, *Server) {
+	t.Helper()
+	s := NewServer(electionday.NewTally(), keys)
+	ts := httptest.NewServer(s)
+	t.Cleanup(ts.Close)
+	return ts, s
+}
+
+// post submits a batch signed with key at the given time.
+func post(t *testing.T, ts *httptest.Server, key []byte, at time.Time, batch Batch) (int, string) {
+	t.Helper()
+	body, _ := json.Marshal(batch)
+	req, _ := http.NewRequest(http.MethodPost, ts.URL+"/batches", bytes.NewReader(body))
+	req.Header.Set("X-Timestamp", strconv.FormatInt(at.Unix(), 10))
+	req.Header.Set("X-Signature", Sign(key, at, body))
+	resp, err := ts.Client().Do(req)
+	if err != nil {
+		// Called from other goroutines too, so no t.Fatal.
+		t.Error(err)
+		return 0, ""
+	}
+	defer resp.Body.Close()
+	var reply bytes.Buffer
+	reply.ReadFrom(resp.Body)
+	return resp.StatusCode, strings.TrimSpace(reply.String())
+}
+
+func TestSubmitBatches(t *testing.T) {
+	ts, s := newTestServer(t)
+	now := time.Unix(1730800000, 0)
+	s.now = func() time.Time { return now }
+	north := Batch{Station: "north-1", Votes: map[string]int{"Mary": 12, "John": 7}}
+
+	tests := []struct {
+		name   string
+		key    []byte
+		at     time.Time
+		batch  Batch
+		status int
+		reply  string
+	}{
+		{name: "new batch", key: keys["north-1"], at: now, batch: north, status: http.StatusCreated,
+			reply: `{"results":[{"name":"Mary","votes":12},{"name":"John","votes":7}],"stations":1}`},
+		{name: "same batch again", key: keys["north-1"], at: now, batch: north, status: http.StatusOK,
+			reply: `{"results":[{"name":"Mary","votes":12},{"name":"John","votes":7}],"stations":1}`},
+		{name: "changed batch", key: keys["north-1"], at: now,
+			batch: Batch{Station: "north-1", Votes: map[string]int{"Mary": 13}}, status: http.StatusConflict},
+		{name: "wrong key", key: keys["south-1"], at: now, batch: north, status: http.StatusUnauthorized,
+			reply: `{"error":"bad signature"}`},
+		{name: "unknown station", key: keys["north-1"], at: now,
+			batch: Batch{Station: "east-1", Votes: map[string]int{"Mary": 1}}, status: http.StatusUnauthorized},
+		{name: "stale timestamp", key: keys["south-1"], at: now.Add(-time.Hour),
+			batch: Batch{Station: "south-1", Votes: map[string]int{"John": 1}}, status: http.StatusUnauthorized,
+			reply: `{"error":"timestamp is 1h0m0s off"}`},
+		{name: "negative votes", key: keys["south-1"], at: now,
+			batch: Batch{Station: "south-1", Votes: map[string]int{"John": -1}}, status: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, reply := post(t, ts, tt.key, tt.at, tt.batch)
+			if status != tt.status || (tt.reply != "" && reply != tt.reply) {
+				t.Errorf("got %d %s, want %d %s", status, reply, tt.status, tt.reply)
+			}
+		})
+	}
+
+	// A tampered body does not match the signature.
+	body := `{"station":"south-1","votes":{"John":5}}`
+	req, _ := http.NewRequest(http.MethodPost, ts.URL+"/batches", strings.NewReader(strings.Replace(body, "5", "50", 1)))
+	req.Header.Set("X-Timestamp", strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set("X-Signature", Sign(keys["south-1"], now, []byte(body)))
+	resp, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("tampered body: status %d, want 401", resp.StatusCode)
+	}
+
+	resp, err = ts.Client().Get(ts.URL + "/results")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var st Standings
+	if err := json.NewDecoder(resp.Body).Decode(&st); err != nil {
+		t.Fatal(err)
+	}
+	if st.Stations != 1 || len(st.Results) != 2 || st.Results[0] != (Result{Name: "Mary", Votes: 12}) {
+		t.Errorf("GET /results = %+v", st)
+	}
+}
+
+// readEvents returns the standings sent on an event stream.
+func readEvents(t *testing.T, ts *httptest.Server) (<-chan Standings, func()) {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+"/events", nil)
+	resp, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+	events := make(chan Standings, 100)
+	go func() {
+		defer close(events)
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+			if !ok {
+				continue
+			}
+			var st Standings
+			if err := json.Unmarshal([]byte(data), &st); err != nil {
+				t.Errorf("bad event data %q: %v", data, err)
+				return
+			}
+			events <- st
+		}
+	}()
+	return events, func() { resp.Body.Close() }
+}
+
+func next(t *testing.T, events <-chan Standings) Standings {
+	t.Helper()
+	select {
+	case st, ok := <-events:
+		if !ok {
+			t.Fatal("event stream ended")
+		}
+		return st
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event after 5s")
+	}
+	return Standings{}
+}
+
+func TestEvents(t *testing.T) {
+	ts, s := newTestServer(t)
+	events, stop := readEvents(t, ts)
+	defer stop()
+
+	if st := next(t, events); st.Stations != 0 || len(st.Results) != 0 {
+		t.Errorf("first event = %+v, want empty standings", st)
+	}
+	now := time.Now()
+	post(t, ts, keys["north-1"], now, Batch{Station: "north-1", Votes: map[string]int{"Mary": 12}})
+	if st := next(t, events); st.Stations != 1 || st.Results[0].Votes != 12 {
+		t.Errorf("event after a batch = %+v", st)
+	}
+	// A resubmission changes nothing, so nothing is sent for it.
+	post(t, ts, keys["north-1"], now, Batch{Station: "north-1", Votes: map[string]int{"Mary": 12}})
+	post(t, ts, keys["south-1"], now, Batch{Station: "south-1", Votes: map[string]int{"John": 20}})
+	if st := next(t, events); st.Stations != 2 || st.Results[0] != (Result{Name: "John", Votes: 20}) {
+		t.Errorf("event after the second batch = %+v", st)
+	}
+
+	stop()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		s.mu.Lock()
+		n := len(s.subscribers)
+		s.mu.Unlock()
+		if n == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("subscriber still registered after the client went away")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestConcurrentSubmissions(t *testing.T) {
+	stations := make(map[string][]byte)
+	for i := range 50 {
+		stations[fmt.Sprintf("station-%d", i)] = []byte(fmt.Sprintf("key-%d", i))
+	}
+	s := NewServer(electionday.NewTally(), stations)
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+	events, stop := readEvents(t, ts)
+	defer stop()
+	next(t, events)
+
+	var wg sync.WaitGroup
+	now := time.Now()
+	for name, key := range stations {
+		for range 2 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				post(t, ts, key, now, Batch{Station: name, Votes: map[string]int{"Mary": 1, "John": 2}})
+			}()
+		}
+	}
+	wg.Wait()
+
+	// Events only move forward, and the last one has every station.
+	last := 0
+	for last < len(stations) {
+		st := next(t, events)
+		if st.Stations < last {
+			t.Fatalf("event with %d stations after one with %d", st.Stations, last)
+		}
+		last = st.Stations
+	}
+	if st := s.standings(); st.Results[0] != (Result{Name: "John", Votes: 100}) {
+		t.Errorf("standings = %+v", st)
+	}
+}
+
+// failingReader fails partway through the body.
+type failingReader struct{ sent bool }
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if r.sent {
+		return 0, errors.New("connection reset")
+	}
+	r.sent = true
+	return copy(p, `{"station":`), nil
+}
+
+func TestSubmitReadErrors(t *testing.T) {
+	s := NewServer(electionday.NewTally(), keys)
+	for _, tt := range []struct {
+		name   string
+		body   io.Reader
+		status int
+	}{
+		{name: "too large", body: strings.NewReader(strings.Repeat(" ", maxBatchSize+1)), status: http.StatusRequestEntityTooLarge},
+		{name: "read error", body: &failingReader{}, status: http.StatusBadRequest},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			s.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/batches", tt.body))
+			if w.Code != tt.status {
+				t.Errorf("status %d, want %d", w.Code, tt.status)
+			}
+		})
+	}
+}
@@ -0,0 +1,288 @@
+package electionday
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// ReportOptions add context to a report. The zero value reports the
+// results on their own.
+type ReportOptions struct {
+	Title string
+	// Electorate is the number of registered voters, for the turnout.
+	Electorate int
+	// Rejected is the number of ballots cast but not counted for anyone.
+	// They count towards the turnout, not the vote shares.
+	Rejected int
+	// Threshold is the vote share in percent a candidate needs, e.g. to
+	// win seats. Zero means no threshold.
+	Threshold float64
+	// RecountMargin is the lead in percentage points under which the
+	// top two candidates are flagged for a recount.
+	RecountMargin float64
+	// Previous holds the results of the previous election to compare with.
+	Previous []*ElectionResult
+}
+
+// ReportRow is one candidate's line in a report.
+type ReportRow struct {
+	// Rank is shared by tied candidates: two candidates tied for first
+	// are both ranked 1 and the next one 3.
+	Rank  int     `json:"rank"`
+	Name  string  `json:"name"`
+	Votes int     `json:"votes"`
+	Share float64 `json:"share"`
+	// PassesThreshold is true when there is no threshold.
+	PassesThreshold bool `json:"passes_threshold"`
+	// Previous results, if the candidate stood in the previous election
+	// of ReportOptions.Previous, and the change in share in points.
+	PreviousVotes *int     `json:"previous_votes,omitempty"`
+	PreviousShare *float64 `json:"previous_share,omitempty"`
+	Change        *float64 `json:"change,omitempty"`
+}
+
+// Report describes an election result in detail. Percentages are rounded
+// to two decimals.
+type Report struct {
+	Title      string      `json:"title,omitempty"`
+	TotalVotes int         `json:"total_votes"`
+	Rejected   int         `json:"rejected,omitempty"`
+	Electorate int         `json:"electorate,omitempty"`
+	Turnout    float64     `json:"turnout,omitempty"`
+	Threshold  float64     `json:"threshold,omitempty"`
+	Rows       []ReportRow `json:"results"`
+	// Margin is the lead of the first candidate over the second, in votes
+	// and in points.
+	Margin      int     `json:"margin"`
+	MarginShare float64 `json:"margin_share"`
+	// Ties lists every group of candidates with the same number of votes.
+	Ties [][]string `json:"ties,omitempty"`
+	// Recount is set when the top two are tied or closer than
+	// ReportOptions.RecountMargin.
+	Recount bool `json:"recount"`
+
+	compared bool
+}
+
+func percent(part, whole int) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return math.Round(10000*float64(part)/float64(whole)) / 100
+}
+
+// NewReport builds a report of the results. Results for the same name are
+// added up.
+func NewReport(results []*ElectionResult, opts ReportOptions) *Report {
+	r := &Report{Title: opts.Title, Rejected: opts.Rejected, Electorate: opts.Electorate, Threshold: opts.Threshold}
+	votes := make(map[string]int)
+	for _, result := range results {
+		votes[result.Name] += result.Votes
+		r.TotalVotes += result.Votes
+	}
+	if opts.Electorate > 0 {
+		r.Turnout = percent(r.TotalVotes+opts.Rejected, opts.Electorate)
+	}
+
+	previous := make(map[string]int)
+	previousTotal := 0
+	for _, result := range opts.Previous {
+		previous[result.Name] += result.Votes
+		previousTotal += result.Votes
+	}
+	r.compared = opts.Previous != nil
+
+	for i, result := range sortedResults(votes) {
+		row := ReportRow{Rank: i + 1, Name: result.Name, Votes: result.Votes, Share: percent(result.Votes, r.TotalVotes)}
+		if i > 0 && result.Votes == r.Rows[i-1].Votes {
+			row.Rank = r.Rows[i-1].Rank
+		}
+		// Share is rounded for display, so decide on the exact votes.
+		row.PassesThreshold = float64(result.Votes)*100 >= opts.Threshold*float64(r.TotalVotes)
+		if r.TotalVotes == 0 {
+			row.PassesThreshold = opts.Threshold <= 0
+		}
+		if n, ok := previous[result.Name]; ok {
+			share := percent(n, previousTotal)
+			change := math.Round(100*(row.Share-share)) / 100
+			row.PreviousVotes, row.PreviousShare, row.Change = &n, &share, &change
+		}
+		r.Rows = append(r.Rows, row)
+	}
+
+	// Rows with the same votes are next to each other and share a rank.
+	for i := 1; i < len(r.Rows); i++ {
+		switch {
+		case r.Rows[i].Rank != r.Rows[i-1].Rank:
+		case i >= 2 && r.Rows[i-2].Rank == r.Rows[i].Rank:
+			last := &r.Ties[len(r.Ties)-1]
+			*last = append(*last, r.Rows[i].Name)
+		default:
+			r.Ties = append(r.Ties, []string{r.Rows[i-1].Name, r.Rows[i].Name})
+		}
+	}
+
+	if len(r.Rows) >= 2 {
+		r.Margin = r.Rows[0].Votes - r.Rows[1].Votes
+		exact := 0.0
+		if r.TotalVotes > 0 {
+			exact = float64(r.Margin) * 100 / float64(r.TotalVotes)
+		}
+		r.MarginShare = math.Round(100*exact) / 100
+		r.Recount = r.Margin == 0 || exact < opts.RecountMargin
+	}
+	return r
+}
+
+// formatShare writes a percentage with one decimal.
+func formatShare(share float64) string {
+	return strconv.FormatFloat(share, 'f', 1, 64) + "%"
+}
+
+func formatChange(change *float64) string {
+	if change == nil {
+		return "new"
+	}
+	return fmt.Sprintf("%+.1f", *change)
+}
+
+// table returns the header and cells of the results table shared by the
+// text and Markdown output, leaving out the columns that do not apply.
+func (r *Report) table() [][]string {
+	header := []string{"Rank", "Candidate", "Votes", "Share"}
+	if r.compared {
+		header = append(header, "Change")
+	}
+	if r.Threshold > 0 {
+		header = append(header, "Threshold")
+	}
+	table := [][]string{header}
+	for i, row := range r.Rows {
+		rank := strconv.Itoa(row.Rank)
+		if (i > 0 && r.Rows[i-1].Rank == row.Rank) || (i+1 < len(r.Rows) && r.Rows[i+1].Rank == row.Rank) {
+			rank += "="
+		}
+		cells := []string{rank, row.Name, strconv.Itoa(row.Votes), formatShare(row.Share)}
+		if r.compared {
+			cells = append(cells, formatChange(row.Change))
+		}
+		if r.Threshold > 0 {
+			passes := "below"
+			if row.PassesThreshold {
+				passes = "passes"
+			}
+			cells = append(cells, passes)
+		}
+		table = append(table, cells)
+	}
+	return table
+}
+
+// notes returns the sentences under the table: turnout, margin, ties and
+// recount.
+func (r *Report) notes() []string {
+	var notes []string
+	if r.Electorate > 0 {
+		notes = append(notes, fmt.Sprintf("Turnout: %d of %d registered voters (%s).",
+			r.TotalVotes+r.Rejected, r.Electorate, formatShare(r.Turnout)))
+	}
+	if r.Rejected > 0 {
+		notes = append(notes, fmt.Sprintf("Rejected ballots: %d.", r.Rejected))
+	}
+	if r.Threshold > 0 {
+		notes = append(notes, fmt.Sprintf("Threshold: %s of the votes.", formatShare(r.Threshold)))
+	}
+	if len(r.Rows) >= 2 && r.Margin > 0 {
+		notes = append(notes, fmt.Sprintf("Margin: %s leads %s by %d votes (%.1f points).",
+			r.Rows[0].Name, r.Rows[1].Name, r.Margin, r.MarginShare))
+	}
+	for _, tie := range r.Ties {
+		notes = append(notes, fmt.Sprintf("Tie: %s.", strings.Join(tie, ", ")))
+	}
+	if r.Recount {
+		notes = append(notes, "Recount: the top two candidates are within the recount margin.")
+	}
+	return notes
+}
+
+// WriteText writes the report as an aligned plain text table.
+func (r *Report) WriteText(w io.Writer) error {
+	var sb strings.Builder
+	if r.Title != "" {
+		sb.WriteString(r.Title + "\n" + strings.Repeat("=", len(r.Title)) + "\n\n")
+	}
+	tw := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	for _, cells := range r.table() {
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+	tw.Flush()
+	if notes := r.notes(); len(notes) > 0 {
+		sb.WriteString("\n" + strings.Join(notes, "\n") + "\n")
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// WriteMarkdown writes the report as a Markdown table.
+func (r *Report) WriteMarkdown(w io.Writer) error {
+	var sb strings.Builder
+	if r.Title != "" {
+		sb.WriteString("## " + r.Title + "\n\n")
+	}
+	for i, cells := range r.table() {
+		sb.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+		if i == 0 {
+			// Numbers are right-aligned.
+			align := make([]string, len(cells))
+			for j, name := range cells {
+				align[j] = "---"
+				if name == "Votes" || name == "Share" || name == "Change" {
+					align[j] = "---:"
+				}
+			}
+			sb.WriteString("| " + strings.Join(align, " | ") + " |\n")
+		}
+	}
+	if notes := r.notes(); len(notes) > 0 {
+		sb.WriteString("\n")
+		for _, note := range notes {
+			sb.WriteString("- " + note + "\n")
+		}
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// WriteCSV writes one row per candidate with every column, leaving the
+// previous election's columns empty for new candidates.
+func (r *Report) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"rank", "candidate", "votes", "share", "passes_threshold", "previous_votes", "previous_share", "change"})
+	for _, row := range r.Rows {
+		record := []string{
+			strconv.Itoa(row.Rank), row.Name, strconv.Itoa(row.Votes),
+			strconv.FormatFloat(row.Share, 'f', 2, 64), strconv.FormatBool(row.PassesThreshold), "", "", "",
+		}
+		if row.PreviousVotes != nil {
+			record[5] = strconv.Itoa(*row.PreviousVotes)
+			record[6] = strconv.FormatFloat(*row.PreviousShare, 'f', 2, 64)
+			record[7] = strconv.FormatFloat(*row.Change, 'f', 2, 64)
+		}
+		cw.Write(record)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteJSON writes the report as indented JSON.
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
@@ -0,0 +1,131 @@
+package electionday
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+func checkGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading golden file: %v (run go test -update to create it)", err)
+	}
+	if got != string(want) {
+		t.Errorf("output differs from %s:\n%s\nwant:\n%s", path, got, want)
+	}
+}
+
+func results(votes ...any) []*ElectionResult {
+	var rs []*ElectionResult
+	for i := 0; i < len(votes); i += 2 {
+		rs = append(rs, NewElectionResult(votes[i].(string), votes[i+1].(int)))
+	}
+	return rs
+}
+
+var (
+	mayor = NewReport(results("Mary", 3150, "John", 3120, "Ann", 410, "Bob", 320), ReportOptions{
+		Title:         "Mayor 2024",
+		Electorate:    9800,
+		Rejected:      55,
+		Threshold:     5,
+		RecountMargin: 0.5,
+		Previous:      results("John", 3400, "Mary", 2900, "Bob", 700),
+	})
+	council = NewReport(results("A", 120, "B", 120, "C", 90, "D", 60, "E", 60, "F", 60), ReportOptions{})
+)
+
+func TestReportGolden(t *testing.T) {
+	tests := []struct {
+		name   string
+		report *Report
+		write  func(*Report, io.Writer) error
+	}{
+		{name: "mayor.txt", report: mayor, write: (*Report).WriteText},
+		{name: "mayor.md", report: mayor, write: (*Report).WriteMarkdown},
+		{name: "mayor.csv", report: mayor, write: (*Report).WriteCSV},
+		{name: "mayor.json", report: mayor, write: (*Report).WriteJSON},
+		{name: "council.txt", report: council, write: (*Report).WriteText},
+		{name: "council.md", report: council, write: (*Report).WriteMarkdown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tt.write(tt.report, &buf); err != nil {
+				t.Fatal(err)
+			}
+			checkGolden(t, tt.name, buf.String())
+		})
+	}
+}
+
+func TestReportFigures(t *testing.T) {
+	if mayor.TotalVotes != 7000 || mayor.Turnout != 71.99 || mayor.Margin != 30 || mayor.MarginShare != 0.43 || !mayor.Recount {
+		t.Errorf("mayor: total %d, turnout %v, margin %d (%v points), recount %v",
+			mayor.TotalVotes, mayor.Turnout, mayor.Margin, mayor.MarginShare, mayor.Recount)
+	}
+	ann, bob := mayor.Rows[2], mayor.Rows[3]
+	if ann.Change != nil || !ann.PassesThreshold || bob.PassesThreshold || *bob.Change != -5.43 {
+		t.Errorf("Ann = %+v, Bob = %+v", ann, bob)
+	}
+
+	var ranks []int
+	for _, row := range council.Rows {
+		ranks = append(ranks, row.Rank)
+	}
+	if !slices.Equal(ranks, []int{1, 1, 3, 4, 4, 4}) {
+		t.Errorf("council ranks = %v", ranks)
+	}
+	if len(council.Ties) != 2 || !slices.Equal(council.Ties[1], []string{"D", "E", "F"}) || !council.Recount {
+		t.Errorf("council ties = %q, recount %v", council.Ties, council.Recount)
+	}
+
+	// The JSON output decodes back into the same report.
+	var buf bytes.Buffer
+	if err := mayor.WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var decoded Report
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.TotalVotes != mayor.TotalVotes || len(decoded.Rows) != 4 || *decoded.Rows[0].PreviousVotes != 2900 {
+		t.Errorf("decoded report = %+v", decoded)
+	}
+}
+
+// Thresholds and recounts are decided on the exact votes, not the shares
+// rounded for display.
+func TestReportRounding(t *testing.T) {
+	r := NewReport(results("A", 50249, "B", 44755, "C", 4996), ReportOptions{Threshold: 5, RecountMargin: 0.5})
+	if c := r.Rows[2]; c.Share != 5 || c.PassesThreshold {
+		t.Errorf("C with 4.996%%: share %v, passes threshold %v; want 5, false", c.Share, c.PassesThreshold)
+	}
+
+	// 498 votes of 100000 is 0.498 points, under the recount margin,
+	// though the rounded shares 50.25 and 49.75 are 0.5 apart.
+	r = NewReport(results("A", 50249, "B", 49751), ReportOptions{RecountMargin: 0.5})
+	if r.MarginShare != 0.5 || !r.Recount {
+		t.Errorf("margin share %v, recount %v; want 0.5, true", r.MarginShare, r.Recount)
+	}
+	r = NewReport(results("A", 50250, "B", 49750), ReportOptions{RecountMargin: 0.5})
+	if r.Recount {
+		t.Error("recount with a margin of exactly 0.5 points")
+	}
+}
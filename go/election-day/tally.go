@@ -0,0 +1,222 @@
+package electionday
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	// ErrInvalidBatch is returned for a batch without a station ID or
+	// with negative vote counts.
+	ErrInvalidBatch = errors.New("invalid batch")
+	// ErrConflictingBatch is returned when a station submits a batch that
+	// differs from the one it already submitted.
+	ErrConflictingBatch = errors.New("station already submitted a different batch")
+)
+
+// Tally counts votes per candidate and is safe for concurrent use by many
+// polling stations.
+//
+// Single updates only share a read lock and add to a per-candidate atomic
+// counter, so stations do not wait for each other. Snapshot takes the
+// write lock, which makes every snapshot consistent: a batch is either
+// fully in it or not at all. A tally made by NewAuditedTally also records
+// every change in an AuditLog; its changes all take the write lock, so
+// that they are logged in the order they were applied.
+type Tally struct {
+	mu       sync.RWMutex
+	counters map[string]*atomic.Int64
+
+	stationsMu sync.Mutex
+	stations   map[string]map[string]int
+
+	audit *AuditLog // nil when changes are not audited
+}
+
+// NewTally returns an empty tally.
+func NewTally() *Tally {
+	return &Tally{
+		counters: make(map[string]*atomic.Int64),
+		stations: make(map[string]map[string]int),
+	}
+}
+
+// NewAuditedTally returns an empty tally that records every change in log.
+func NewAuditedTally(log *AuditLog) *Tally {
+	t := NewTally()
+	t.audit = log
+	return t
+}
+
+// apply adds votes and, for an audited tally, records the change. The
+// record is appended after the change and under the write lock, so the
+// log has the changes in the order they were applied.
+func (t *Tally) apply(station, reason string, votes map[string]int) {
+	if t.audit == nil {
+		t.add(votes)
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.addLocked(votes)
+	t.audit.Append(station, reason, votes)
+}
+
+// add adds votes under the read lock when every candidate already has a
+// counter, and otherwise under the write lock, creating the missing ones.
+// Either way the whole batch lands between two snapshots.
+func (t *Tally) add(votes map[string]int) {
+	t.mu.RLock()
+	known := true
+	for candidate := range votes {
+		if _, ok := t.counters[candidate]; !ok {
+			known = false
+			break
+		}
+	}
+	if known {
+		for candidate, n := range votes {
+			t.counters[candidate].Add(int64(n))
+		}
+		t.mu.RUnlock()
+		return
+	}
+	t.mu.RUnlock()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.addLocked(votes)
+}
+
+// addLocked adds votes with the write lock held.
+func (t *Tally) addLocked(votes map[string]int) {
+	for candidate, n := range votes {
+		c, ok := t.counters[candidate]
+		if !ok {
+			c = new(atomic.Int64)
+			t.counters[candidate] = c
+		}
+		c.Add(int64(n))
+	}
+}
+
+// VoteCount returns the votes of a candidate, 0 for an unknown one.
+func (t *Tally) VoteCount(candidate string) int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if c, ok := t.counters[candidate]; ok {
+		return int(c.Load())
+	}
+	return 0
+}
+
+// IncrementVoteCount adds increment votes to a candidate, adding the
+// candidate to the tally if needed.
+func (t *Tally) IncrementVoteCount(candidate string, increment int) {
+	if t.audit != nil {
+		t.apply("", "IncrementVoteCount", map[string]int{candidate: increment})
+		return
+	}
+	t.mu.RLock()
+	if c, ok := t.counters[candidate]; ok {
+		c.Add(int64(increment))
+		t.mu.RUnlock()
+		return
+	}
+	t.mu.RUnlock()
+	t.add(map[string]int{candidate: increment})
+}
+
+// DecrementVotesOfCandidate takes one vote away from a candidate already
+// in the tally.
+func (t *Tally) DecrementVotesOfCandidate(candidate string) {
+	if t.audit != nil {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if c, ok := t.counters[candidate]; ok {
+			c.Add(-1)
+			t.audit.Append("", "DecrementVotesOfCandidate", map[string]int{candidate: -1})
+		}
+		return
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if c, ok := t.counters[candidate]; ok {
+		c.Add(-1)
+	}
+}
+
+// Adjust adds votes, which may be negative, on behalf of a station, e.g.
+// to correct a miscount. The station and reason are only kept in the
+// audit log; the batch a station submitted is left as it was.
+func (t *Tally) Adjust(station, reason string, votes map[string]int) {
+	t.apply(station, reason, votes)
+}
+
+// SubmitBatch adds the votes counted at a polling station. Submissions are
+// idempotent per station: sending the same batch again changes nothing
+// and reports false, and sending a different one is ErrConflictingBatch.
+func (t *Tally) SubmitBatch(station string, votes map[string]int) (bool, error) {
+	if station == "" {
+		return false, fmt.Errorf("%w: missing station ID", ErrInvalidBatch)
+	}
+	for candidate, n := range votes {
+		if n < 0 {
+			return false, fmt.Errorf("%w: %d votes for %q from station %q", ErrInvalidBatch, n, candidate, station)
+		}
+	}
+
+	t.stationsMu.Lock()
+	if previous, ok := t.stations[station]; ok {
+		t.stationsMu.Unlock()
+		if !maps.Equal(previous, votes) {
+			return false, fmt.Errorf("%w: station %q", ErrConflictingBatch, station)
+		}
+		return false, nil
+	}
+	t.stations[station] = maps.Clone(votes)
+	t.stationsMu.Unlock()
+
+	t.apply(station, "SubmitBatch", votes)
+	return true, nil
+}
+
+// Stations returns the IDs of the stations that submitted a batch, sorted.
+func (t *Tally) Stations() []string {
+	t.stationsMu.Lock()
+	defer t.stationsMu.Unlock()
+	return slices.Sorted(maps.Keys(t.stations))
+}
+
+// Snapshot returns the votes of every candidate at one instant.
+func (t *Tally) Snapshot() map[string]int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	snapshot := make(map[string]int, len(t.counters))
+	for candidate, c := range t.counters {
+		snapshot[candidate] = int(c.Load())
+	}
+	return snapshot
+}
+
+// Results returns a snapshot as election results, most votes first and
+// then by name.
+func (t *Tally) Results() []*ElectionResult {
+	return sortedResults(t.Snapshot())
+}
+
+func sortedResults(votes map[string]int) []*ElectionResult {
+	results := make([]*ElectionResult, 0, len(votes))
+	for name, n := range votes {
+		results = append(results, NewElectionResult(name, n))
+	}
+	slices.SortFunc(results, func(a, b *ElectionResult) int {
+		return cmp.Or(cmp.Compare(b.Votes, a.Votes), cmp.Compare(a.Name, b.Name))
+	})
+	return results
+}
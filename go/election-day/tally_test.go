@@ -0,0 +1,123 @@
+package electionday
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestTallyMethods(t *testing.T) {
+	tally := NewTally()
+	tally.IncrementVoteCount("John", 3)
+	tally.IncrementVoteCount("Mary", 1)
+	tally.DecrementVotesOfCandidate("John")
+	tally.DecrementVotesOfCandidate("Nobody")
+
+	for candidate, want := range map[string]int{"John": 2, "Mary": 1, "Nobody": 0} {
+		if got := tally.VoteCount(candidate); got != want {
+			t.Errorf("VoteCount(%q) = %d, want %d", candidate, got, want)
+		}
+	}
+	if _, ok := tally.Snapshot()["Nobody"]; ok {
+		t.Errorf("DecrementVotesOfCandidate added an unknown candidate")
+	}
+	results := tally.Results()
+	if len(results) != 2 || DisplayResult(results[0]) != "John (2)" || DisplayResult(results[1]) != "Mary (1)" {
+		t.Errorf("Results() = %v", results)
+	}
+}
+
+func TestTallySubmitBatch(t *testing.T) {
+	tally := NewTally()
+	batch := map[string]int{"John": 10, "Mary": 7}
+
+	if applied, err := tally.SubmitBatch("north-1", batch); !applied || err != nil {
+		t.Fatalf("SubmitBatch() = %v, %v, want true, nil", applied, err)
+	}
+	batch["John"] = 99 // the tally keeps its own copy
+	if applied, err := tally.SubmitBatch("north-1", map[string]int{"John": 10, "Mary": 7}); applied || err != nil {
+		t.Errorf("resubmitting the same batch = %v, %v, want false, nil", applied, err)
+	}
+	if _, err := tally.SubmitBatch("north-1", map[string]int{"John": 11, "Mary": 7}); !errors.Is(err, ErrConflictingBatch) {
+		t.Errorf("submitting a different batch error = %v, want ErrConflictingBatch", err)
+	}
+	if _, err := tally.SubmitBatch("", map[string]int{"John": 1}); !errors.Is(err, ErrInvalidBatch) {
+		t.Errorf("submitting without a station error = %v, want ErrInvalidBatch", err)
+	}
+	if _, err := tally.SubmitBatch("south-1", map[string]int{"John": -1}); !errors.Is(err, ErrInvalidBatch) {
+		t.Errorf("submitting negative votes error = %v, want ErrInvalidBatch", err)
+	}
+
+	if got := tally.VoteCount("John"); got != 10 {
+		t.Errorf("VoteCount(John) = %d, want 10", got)
+	}
+	if got := tally.Stations(); len(got) != 1 || got[0] != "north-1" {
+		t.Errorf("Stations() = %v, want [north-1]", got)
+	}
+}
+
+// TestTallyConcurrent is meant to be run with -race.
+func TestTallyConcurrent(t *testing.T) {
+	const (
+		stations = 200
+		retries  = 3
+		voters   = 300
+		votes    = 50
+	)
+	tally := NewTally()
+	var wg sync.WaitGroup
+
+	// Every station submits its batch several times at once; each batch
+	// gives one vote to both Alice and Bob.
+	for s := 0; s < stations; s++ {
+		for r := 0; r < retries; r++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := tally.SubmitBatch(fmt.Sprintf("station-%d", s), map[string]int{"Alice": 1, "Bob": 1}); err != nil {
+					t.Error(err)
+				}
+			}()
+		}
+	}
+	for v := 0; v < voters; v++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			candidate := fmt.Sprintf("write-in-%d", v%7)
+			for i := 0; i < votes; i++ {
+				tally.IncrementVoteCount(candidate, 2)
+				tally.DecrementVotesOfCandidate(candidate)
+				_ = tally.VoteCount(candidate)
+			}
+		}()
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 200; i++ {
+			snapshot := tally.Snapshot()
+			if snapshot["Alice"] != snapshot["Bob"] {
+				t.Errorf("snapshot saw half a batch: %v", snapshot)
+				return
+			}
+		}
+	}()
+	wg.Wait()
+	<-done
+
+	if got := tally.VoteCount("Alice"); got != stations {
+		t.Errorf("VoteCount(Alice) = %d, want %d", got, stations)
+	}
+	total := 0
+	for i := 0; i < 7; i++ {
+		total += tally.VoteCount(fmt.Sprintf("write-in-%d", i))
+	}
+	if total != voters*votes {
+		t.Errorf("write-in votes = %d, want %d", total, voters*votes)
+	}
+	if got := len(tally.Stations()); got != stations {
+		t.Errorf("len(Stations()) = %d, want %d", got, stations)
+	}
+}
@@ -0,0 +1,389 @@
+package electionday
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math/bits"
+	"slices"
+)
+
+var (
+	// ErrInvalidElection is returned for an election without candidates,
+	// with duplicate candidates or with more seats than candidates.
+	ErrInvalidElection = errors.New("invalid election")
+	// ErrInvalidBallot is returned for a ballot naming an unknown
+	// candidate, naming one twice or with a negative weight.
+	ErrInvalidBallot = errors.New("invalid ballot")
+)
+
+// Ballot is one voter's ballot.
+type Ballot struct {
+	// Ranking lists candidates from most to least preferred. Candidates
+	// left out are ranked below all others. For approval voting it holds
+	// the approved candidates in any order.
+	Ranking []string
+	// Weight is how many identical ballots this one stands for. Zero
+	// counts as one.
+	Weight int
+}
+
+func (b Ballot) weight() int64 {
+	if b.Weight == 0 {
+		return 1
+	}
+	return int64(b.Weight)
+}
+
+// Election is the input of a voting method.
+type Election struct {
+	// Candidates in ballot paper order, which is also the order used to
+	// break ties: see Method.
+	Candidates []string
+	Ballots    []Ballot
+	// Seats is the number of winners, 1 if zero.
+	Seats int
+}
+
+// Standing is the vote total of a candidate in a round. Votes are
+// fractional only in STV, after surplus transfers.
+type Standing struct {
+	Name  string
+	Votes float64
+}
+
+// Round is one step of a count.
+type Round struct {
+	// Standings of the candidates still in the count, most votes first.
+	Standings []Standing
+	// Exhausted is the value of ballots that no longer count for anyone.
+	Exhausted float64
+	// Elected and Eliminated are the candidates elected and eliminated at
+	// the end of the round.
+	Elected    []string
+	Eliminated []string
+}
+
+// Outcome is the result of a voting method.
+type Outcome struct {
+	Method  string
+	Winners []string
+	Rounds  []Round
+}
+
+// A Method computes the outcome of an election.
+//
+// Ties are broken deterministically. A candidate to eliminate is chosen by
+// looking back at earlier rounds for the most recent one in which the tied
+// candidates had different totals, and eliminating the one with fewest
+// votes there. Any tie that remains, and any tie for a seat, goes to the
+// candidate listed first in Election.Candidates.
+type Method func(e Election) (*Outcome, error)
+
+// Methods are the voting methods by name.
+var Methods = map[string]Method{
+	"irv":      InstantRunoff,
+	"stv":      SingleTransferableVote,
+	"schulze":  Schulze,
+	"borda":    Borda,
+	"approval": Approval,
+}
+
+func (e Election) seats() int {
+	return max(e.Seats, 1)
+}
+
+// validate checks the election and returns the position of every
+// candidate in e.Candidates.
+func (e Election) validate() (map[string]int, error) {
+	if len(e.Candidates) == 0 {
+		return nil, fmt.Errorf("%w: no candidates", ErrInvalidElection)
+	}
+	if e.Seats < 0 || e.Seats > len(e.Candidates) {
+		return nil, fmt.Errorf("%w: %d seats for %d candidates", ErrInvalidElection, e.Seats, len(e.Candidates))
+	}
+	index := make(map[string]int, len(e.Candidates))
+	for i, c := range e.Candidates {
+		if c == "" {
+			return nil, fmt.Errorf("%w: empty candidate name", ErrInvalidElection)
+		}
+		if _, ok := index[c]; ok {
+			return nil, fmt.Errorf("%w: duplicate candidate %q", ErrInvalidElection, c)
+		}
+		index[c] = i
+	}
+	for i, b := range e.Ballots {
+		if b.Weight < 0 {
+			return nil, fmt.Errorf("%w: ballot %d has weight %d", ErrInvalidBallot, i+1, b.Weight)
+		}
+		seen := make(map[string]bool, len(b.Ranking))
+		for _, c := range b.Ranking {
+			if _, ok := index[c]; !ok {
+				return nil, fmt.Errorf("%w: ballot %d names unknown candidate %q", ErrInvalidBallot, i+1, c)
+			}
+			if seen[c] {
+				return nil, fmt.Errorf("%w: ballot %d names %q twice", ErrInvalidBallot, i+1, c)
+			}
+			seen[c] = true
+		}
+	}
+	return index, nil
+}
+
+// scale is the number of units in a vote in STV counts, so transfers keep
+// four decimal places. Fractions of a unit are dropped.
+const scale = 10000
+
+// runoff counts ranked ballots round by round, electing candidates who
+// reach the quota and otherwise eliminating the weakest one, until the
+// seats are filled. Surpluses of elected candidates are passed on to the
+// next preferences at a reduced value (the weighted inclusive Gregory
+// method).
+func runoff(e Election, name string, quota func(active int64) int64) (*Outcome, error) {
+	index, err := e.validate()
+	if err != nil {
+		return nil, err
+	}
+	type ballot struct {
+		ranking []string
+		value   int64
+	}
+	ballots := make([]ballot, len(e.Ballots))
+	var total int64
+	for i, b := range e.Ballots {
+		ballots[i] = ballot{ranking: b.Ranking, value: b.weight() * scale}
+		total += ballots[i].value
+	}
+
+	continuing := slices.Clone(e.Candidates)
+	elected := make(map[string]bool)
+	out := &Outcome{Method: name}
+	var history []map[string]int64
+	seats := e.seats()
+
+	// loses reports whether a should be eliminated rather than b.
+	loses := func(a, b string, votes map[string]int64) bool {
+		if votes[a] != votes[b] {
+			return votes[a] < votes[b]
+		}
+		for r := len(history) - 2; r >= 0; r-- {
+			if history[r][a] != history[r][b] {
+				return history[r][a] < history[r][b]
+			}
+		}
+		return index[a] > index[b]
+	}
+
+	for len(out.Winners) < seats {
+		votes := make(map[string]int64, len(continuing))
+		piles := make(map[string][]int, len(continuing))
+		var exhausted int64
+		for i, b := range ballots {
+			top := ""
+			for _, c := range b.ranking {
+				if slices.Contains(continuing, c) {
+					top = c
+					break
+				}
+			}
+			if top == "" {
+				exhausted += b.value
+				continue
+			}
+			votes[top] += b.value
+			piles[top] = append(piles[top], i)
+		}
+		history = append(history, votes)
+
+		// Order the continuing candidates strongest first.
+		slices.SortStableFunc(continuing, func(a, b string) int {
+			switch {
+			case loses(b, a, votes):
+				return -1
+			case loses(a, b, votes):
+				return 1
+			}
+			return 0
+		})
+		round := Round{Exhausted: float64(exhausted) / scale}
+		for _, c := range continuing {
+			round.Standings = append(round.Standings, Standing{Name: c, Votes: float64(votes[c]) / scale})
+		}
+
+		q := quota(total - exhausted)
+		remaining := seats - len(out.Winners)
+		switch {
+		case len(continuing) <= remaining:
+			round.Elected = slices.Clone(continuing)
+		case votes[continuing[0]] >= q:
+			for _, c := range continuing[:remaining] {
+				if votes[c] >= q {
+					round.Elected = append(round.Elected, c)
+				}
+			}
+		default:
+			round.Eliminated = []string{continuing[len(continuing)-1]}
+		}
+
+		for _, c := range round.Elected {
+			elected[c] = true
+			if surplus := votes[c] - q; surplus > 0 {
+				for _, i := range piles[c] {
+					ballots[i].value = mulDiv(ballots[i].value, surplus, votes[c])
+				}
+			} else {
+				for _, i := range piles[c] {
+					ballots[i].value = 0
+				}
+			}
+		}
+		out.Winners = append(out.Winners, round.Elected...)
+		continuing = slices.DeleteFunc(continuing, func(c string) bool {
+			return elected[c] || slices.Contains(round.Eliminated, c)
+		})
+		out.Rounds = append(out.Rounds, round)
+	}
+	return out, nil
+}
+
+// mulDiv returns a * b / c for 0 <= a, b <= c, without overflowing in the
+// product of two scaled values.
+func mulDiv(a, b, c int64) int64 {
+	hi, lo := bits.Mul64(uint64(a), uint64(b))
+	q, _ := bits.Div64(hi, lo, uint64(c))
+	return int64(q)
+}
+
+// InstantRunoff elects a single winner: each round, the candidate with a
+// majority of the ballots still counting wins, or the candidate with fewest
+// first preferences is eliminated and their ballots move to the next
+// preference. Seats must be 1.
+func InstantRunoff(e Election) (*Outcome, error) {
+	if e.seats() != 1 {
+		return nil, fmt.Errorf("%w: instant-runoff elects one candidate, not %d", ErrInvalidElection, e.Seats)
+	}
+	return runoff(e, "irv", func(active int64) int64 {
+		return (active/scale/2 + 1) * scale
+	})
+}
+
+// SingleTransferableVote elects e.Seats candidates with the Droop quota,
+// floor(ballots / (seats + 1)) + 1, computed once from all ballots. The
+// surplus of an elected candidate is transferred by the Gregory method:
+// every ballot in their pile moves on at the value surplus / total.
+func SingleTransferableVote(e Election) (*Outcome, error) {
+	var total int64
+	for _, b := range e.Ballots {
+		total += b.weight()
+	}
+	quota := (total/int64(e.seats()+1) + 1) * scale
+	return runoff(e, "stv", func(int64) int64 { return quota })
+}
+
+// scored ranks the candidates by score, ties going to the candidate listed
+// first, and elects the top e.Seats in a single round.
+func scored(e Election, name string, index map[string]int, score map[string]int64) *Outcome {
+	candidates := slices.Clone(e.Candidates)
+	slices.SortStableFunc(candidates, func(a, b string) int {
+		return cmp.Or(cmp.Compare(score[b], score[a]), cmp.Compare(index[a], index[b]))
+	})
+	var round Round
+	for _, c := range candidates {
+		round.Standings = append(round.Standings, Standing{Name: c, Votes: float64(score[c])})
+	}
+	round.Elected = candidates[:e.seats()]
+	return &Outcome{Method: name, Winners: round.Elected, Rounds: []Round{round}}
+}
+
+// Borda gives each candidate n-1 points for a first preference, n-2 for a
+// second and so on, where n is the number of candidates. Unranked
+// candidates get no points. The e.Seats candidates with most points win.
+func Borda(e Election) (*Outcome, error) {
+	index, err := e.validate()
+	if err != nil {
+		return nil, err
+	}
+	score := make(map[string]int64, len(e.Candidates))
+	n := len(e.Candidates)
+	for _, b := range e.Ballots {
+		for i, c := range b.Ranking {
+			score[c] += int64(n-1-i) * b.weight()
+		}
+	}
+	return scored(e, "borda", index, score), nil
+}
+
+// Approval counts every candidate on a ballot as approved. The e.Seats
+// candidates with most approvals win.
+func Approval(e Election) (*Outcome, error) {
+	index, err := e.validate()
+	if err != nil {
+		return nil, err
+	}
+	score := make(map[string]int64, len(e.Candidates))
+	for _, b := range e.Ballots {
+		for _, c := range b.Ranking {
+			score[c] += b.weight()
+		}
+	}
+	return scored(e, "approval", index, score), nil
+}
+
+// Schulze compares every pair of candidates by the strength of the
+// strongest path of pairwise wins between them. Candidates are ranked by
+// how many others they beat that way, which is the Schulze ranking, and
+// the top e.Seats win. The standings hold those counts.
+func Schulze(e Election) (*Outcome, error) {
+	index, err := e.validate()
+	if err != nil {
+		return nil, err
+	}
+	n := len(e.Candidates)
+	// d[i][j] is the number of voters preferring candidate i to j.
+	d := make([][]int64, n)
+	for i := range d {
+		d[i] = make([]int64, n)
+	}
+	for _, b := range e.Ballots {
+		ranked := make([]bool, n)
+		for _, c := range b.Ranking {
+			i := index[c]
+			ranked[i] = true
+			for j := range n {
+				if !ranked[j] {
+					d[i][j] += b.weight()
+				}
+			}
+		}
+	}
+
+	// p[i][j] is the strength of the strongest path from i to j.
+	p := make([][]int64, n)
+	for i := range p {
+		p[i] = make([]int64, n)
+		for j := range n {
+			if i != j && d[i][j] > d[j][i] {
+				p[i][j] = d[i][j]
+			}
+		}
+	}
+	for k := range n {
+		for i := range n {
+			for j := range n {
+				if i != j && i != k && j != k {
+					p[i][j] = max(p[i][j], min(p[i][k], p[k][j]))
+				}
+			}
+		}
+	}
+
+	wins := make(map[string]int64, n)
+	for i, c := range e.Candidates {
+		for j := range n {
+			if p[i][j] > p[j][i] {
+				wins[c]++
+			}
+		}
+	}
+	return scored(e, "schulze", index, wins), nil
+}
@@ -0,0 +1,214 @@
+package electionday
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// ballots builds ballots from lines like "42 A>B>C".
+func ballots(lines ...string) []Ballot {
+	var bs []Ballot
+	for _, line := range lines {
+		weight, ranking, _ := strings.Cut(line, " ")
+		n, _ := strconv.Atoi(weight)
+		b := Ballot{Weight: n}
+		if ranking != "" {
+			b.Ranking = strings.Split(ranking, ">")
+		}
+		bs = append(bs, b)
+	}
+	return bs
+}
+
+// tennessee is the capital election example: voters rank the cities by
+// distance from where they live.
+var tennessee = Election{
+	Candidates: []string{"Memphis", "Nashville", "Chattanooga", "Knoxville"},
+	Ballots: ballots(
+		"42 Memphis>Nashville>Chattanooga>Knoxville",
+		"26 Nashville>Chattanooga>Knoxville>Memphis",
+		"15 Chattanooga>Knoxville>Nashville>Memphis",
+		"17 Knoxville>Chattanooga>Nashville>Memphis",
+	),
+}
+
+func TestVotingMethods(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   Method
+		election Election
+		winners  []string
+		rounds   int
+	}{
+		{
+			name:     "IRV Tennessee",
+			method:   InstantRunoff,
+			election: tennessee,
+			winners:  []string{"Knoxville"},
+			rounds:   3,
+		},
+		{
+			name:     "Borda Tennessee",
+			method:   Borda,
+			election: tennessee,
+			winners:  []string{"Nashville"},
+			rounds:   1,
+		},
+		{
+			name:     "Schulze Tennessee",
+			method:   Schulze,
+			election: tennessee,
+			winners:  []string{"Nashville"},
+			rounds:   1,
+		},
+		{
+			// The example from Schulze's paper, with a cycle in the
+			// pairwise results.
+			name:   "Schulze cycle",
+			method: Schulze,
+			election: Election{
+				Candidates: []string{"A", "B", "C", "D", "E"},
+				Ballots: ballots(
+					"5 A>C>B>E>D", "5 A>D>E>C>B", "8 B>E>D>A>C",
+					"3 C>A>B>E>D", "7 C>A>E>B>D", "2 C>B>A>D>E",
+					"7 D>C>E>B>A", "8 E>B>A>D>C",
+				),
+				Seats: 2,
+			},
+			winners: []string{"E", "A"},
+			rounds:  1,
+		},
+		{
+			name:   "STV desserts",
+			method: SingleTransferableVote,
+			election: Election{
+				Candidates: []string{"Oranges", "Pears", "Chocolate", "Strawberries", "Bonbons"},
+				Ballots: ballots(
+					"4 Oranges", "2 Pears>Oranges", "8 Chocolate>Strawberries",
+					"4 Chocolate>Bonbons", "1 Strawberries", "1 Bonbons",
+				),
+				Seats: 3,
+			},
+			winners: []string{"Chocolate", "Oranges", "Strawberries"},
+			rounds:  5,
+		},
+		{
+			name:   "Approval",
+			method: Approval,
+			election: Election{
+				Candidates: []string{"A", "B", "C"},
+				Ballots:    ballots("3 A>B", "2 C", "1 B>C"),
+				Seats:      2,
+			},
+			winners: []string{"B", "A"},
+			rounds:  1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.method(tt.election)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !slices.Equal(got.Winners, tt.winners) {
+				t.Errorf("Winners = %v, want %v", got.Winners, tt.winners)
+			}
+			if len(got.Rounds) != tt.rounds {
+				t.Errorf("%d rounds, want %d: %+v", len(got.Rounds), tt.rounds, got.Rounds)
+			}
+		})
+	}
+}
+
+func TestSTVTransfers(t *testing.T) {
+	got, err := SingleTransferableVote(Election{
+		Candidates: []string{"Oranges", "Pears", "Chocolate", "Strawberries", "Bonbons"},
+		Ballots: ballots(
+			"4 Oranges", "2 Pears>Oranges", "8 Chocolate>Strawberries",
+			"4 Chocolate>Bonbons", "1 Strawberries", "1 Bonbons",
+		),
+		Seats: 3,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Chocolate's surplus of 6 over the quota of 6 moves on at half value.
+	want := []Standing{{"Strawberries", 5}, {"Oranges", 4}, {"Bonbons", 3}, {"Pears", 2}}
+	if round := got.Rounds[1]; !slices.Equal(round.Standings, want) || !slices.Equal(round.Eliminated, []string{"Pears"}) {
+		t.Errorf("second round = %+v, want standings %v and Pears eliminated", round, want)
+	}
+	if last := got.Rounds[4]; last.Exhausted != 3 {
+		t.Errorf("exhausted after Bonbons is eliminated = %v, want 3", last.Exhausted)
+	}
+
+	// Large weights: the surplus of 566666 moves on to B in full.
+	got, err = SingleTransferableVote(Election{
+		Candidates: []string{"A", "B", "C"},
+		Ballots:    ballots("900000 A>B", "100000 C"),
+		Seats:      2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []Standing{{"B", 566666}, {"C", 100000}}
+	if !slices.Equal(got.Winners, []string{"A", "B"}) || !slices.Equal(got.Rounds[1].Standings, want) {
+		t.Errorf("winners %v, second round %+v; want [A B] and %v", got.Winners, got.Rounds[1].Standings, want)
+	}
+}
+
+func TestTieBreaking(t *testing.T) {
+	// A and C tie on 4 votes in the second round; C had fewer in the
+	// first round, so C goes even though it is listed first.
+	got, err := InstantRunoff(Election{
+		Candidates: []string{"C", "B", "A", "D"},
+		Ballots:    ballots("4 A", "5 B", "3 C", "1 D>C", "1 D"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var eliminated []string
+	for _, r := range got.Rounds {
+		eliminated = append(eliminated, r.Eliminated...)
+	}
+	if !slices.Equal(eliminated, []string{"D", "C"}) {
+		t.Errorf("eliminated %v, want [D C]", eliminated)
+	}
+
+	// With nothing to look back at, the candidate listed first wins.
+	for name, method := range Methods {
+		got, err := method(Election{Candidates: []string{"X", "Y"}, Ballots: ballots("1 Y>X", "1 X>Y")})
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !slices.Equal(got.Winners, []string{"X"}) {
+			t.Errorf("%s: Winners = %v, want [X]", name, got.Winners)
+		}
+	}
+}
+
+func TestInvalidElections(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   Method
+		election Election
+		want     error
+	}{
+		{name: "no candidates", method: Borda, election: Election{}, want: ErrInvalidElection},
+		{name: "duplicate candidate", method: Approval, election: Election{Candidates: []string{"A", "A"}}, want: ErrInvalidElection},
+		{name: "too many seats", method: SingleTransferableVote, election: Election{Candidates: []string{"A"}, Seats: 2}, want: ErrInvalidElection},
+		{name: "IRV with two seats", method: InstantRunoff, election: Election{Candidates: []string{"A", "B"}, Seats: 2}, want: ErrInvalidElection},
+		{name: "unknown candidate", method: Schulze, election: Election{Candidates: []string{"A"}, Ballots: ballots("1 B")}, want: ErrInvalidBallot},
+		{name: "repeated candidate", method: InstantRunoff, election: Election{Candidates: []string{"A", "B"}, Ballots: ballots("1 A>B>A")}, want: ErrInvalidBallot},
+		{name: "negative weight", method: Borda, election: Election{Candidates: []string{"A"}, Ballots: []Ballot{{Ranking: []string{"A"}, Weight: -1}}}, want: ErrInvalidBallot},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.method(tt.election); !errors.Is(err, tt.want) {
+				t.Errorf("error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -1,6 +1,6 @@
 package fanin
 
-import "sync"
+import "context"
 
 /*
 Exercise 2 — Channel Fan-In (Merging Multiple Producers)
@@ -25,52 +25,35 @@ Implement MergeGenerators(count, n int) []int:
 */
 
 func MergeGenerators(count, n int) []int {
-	chans := make([]<-chan int, 0, n)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
+	chans := make([]<-chan int, 0, n)
 	for i := 0; i < n; i++ {
-		chans = append(chans, generator(count))
+		chans = append(chans, generator(ctx, count))
 	}
 
-	merged := merge(chans...)
-
 	var result []int
-	for v := range merged {
+	for v := range Merge(ctx, chans...) {
 		result = append(result, v)
 	}
 	return result
 }
 
-func generator(count int) <-chan int {
+// generator sends 0..count-1 on its channel, stopping early if ctx is done.
+func generator(ctx context.Context, count int) <-chan int {
 	ch := make(chan int)
 
 	go func() {
 		defer close(ch)
 		for i := 0; i < count; i++ {
-			ch <- i
+			select {
+			case <-ctx.Done():
+				return
+			case ch <- i:
+			}
 		}
 	}()
 
 	return ch
 }
-
-func merge(chs ...<-chan int) <-chan int {
-	out := make(chan int)
-	var wg sync.WaitGroup
-
-	wg.Add(len(chs))
-	for _, ch := range chs {
-		ch := ch
-		go func() {
-			defer wg.Done()
-			for v := range ch {
-				out <- v
-			}
-		}()
-	}
-
-	go func() {
-		wg.Wait()
-		close(out)
-	}()
-	return out
-}
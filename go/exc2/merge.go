@@ -0,0 +1,57 @@
+package fanin
+
+import (
+	"context"
+	"sync"
+)
+
+// Merge forwards the values of every channel to one output channel, which
+// is closed once all of them are closed or ctx is cancelled. Values from
+// one channel keep their order; values from different channels interleave.
+//
+// Cancelling ctx stops the forwarding goroutines even if nobody reads the
+// output any more, so a consumer that stops early should cancel. Values
+// still in the input channels are left there.
+func Merge[T any](ctx context.Context, chans ...<-chan T) <-chan T {
+	return MergeBuffered(ctx, 0, chans...)
+}
+
+// MergeBuffered is Merge with an output channel buffered to hold size
+// values, which lets fast producers run ahead of the consumer.
+func MergeBuffered[T any](ctx context.Context, size int, chans ...<-chan T) <-chan T {
+	out := make(chan T, size)
+	var wg sync.WaitGroup
+
+	wg.Add(len(chans))
+	for _, ch := range chans {
+		go func() {
+			defer wg.Done()
+			forward(ctx, ch, out)
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out
+}
+
+// forward sends the values of in to out until in is closed or ctx is done.
+func forward[T any](ctx context.Context, in <-chan T, out chan<- T) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case v, ok := <-in:
+			if !ok {
+				return
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case out <- v:
+			}
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package fanin
+
+import (
+	"context"
+	"runtime"
+	"slices"
+	"testing"
+	"time"
+)
+
+// source sends values on an unbuffered channel and closes it.
+func source[T any](values ...T) <-chan T {
+	ch := make(chan T)
+	go func() {
+		defer close(ch)
+		for _, v := range values {
+			ch <- v
+		}
+	}()
+	return ch
+}
+
+// checkNoLeaks fails if the number of goroutines does not drop back to
+// before within a few seconds.
+func checkNoLeaks(t *testing.T, before int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			buf := make([]byte, 1<<16)
+			t.Fatalf("%d goroutines, want %d:\n%s", runtime.NumGoroutine(), before, buf[:runtime.Stack(buf, true)])
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	var got []string
+	for v := range Merge(context.Background(), source("a", "b", "c"), source("d", "e"), source[string]()) {
+		got = append(got, v)
+	}
+	slices.Sort(got)
+	if want := []string{"a", "b", "c", "d", "e"}; !slices.Equal(got, want) {
+		t.Errorf("Merge = %q, want %q", got, want)
+	}
+
+	// Values from one channel keep their order.
+	var evens, odds []int
+	for v := range MergeBuffered(context.Background(), 4, source(0, 2, 4, 6, 8), source(1, 3, 5, 7, 9)) {
+		if v%2 == 0 {
+			evens = append(evens, v)
+		} else {
+			odds = append(odds, v)
+		}
+	}
+	if !slices.Equal(evens, []int{0, 2, 4, 6, 8}) || !slices.Equal(odds, []int{1, 3, 5, 7, 9}) {
+		t.Errorf("per-channel order lost: %v %v", evens, odds)
+	}
+
+	if _, ok := <-Merge[int](context.Background()); ok {
+		t.Error("Merge of no channels sent a value")
+	}
+}
+
+func TestMergeCancelNoLeaks(t *testing.T) {
+	for _, size := range []int{0, 3} {
+		before := runtime.NumGoroutine()
+		ctx, cancel := context.WithCancel(context.Background())
+
+		// Producers that never finish on their own, and a consumer that
+		// reads a few values and walks away.
+		var chans []<-chan int
+		for range 5 {
+			chans = append(chans, generator(ctx, 1<<30))
+		}
+		out := MergeBuffered(ctx, size, chans...)
+		for range 10 {
+			<-out
+		}
+		cancel()
+
+		checkNoLeaks(t, before)
+		// The output is closed once the forwarders are gone.
+		for range out {
+		}
+	}
+}
+
+func TestMergeCancelBlockedSources(t *testing.T) {
+	before := runtime.NumGoroutine()
+	ctx, cancel := context.WithCancel(context.Background())
+	// Sources that never send nor close.
+	idle := make(chan int)
+	out := Merge(ctx, idle, idle)
+
+	cancel()
+	select {
+	case _, ok := <-out:
+		if ok {
+			t.Fatal("got a value from idle sources")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("output not closed after cancel")
+	}
+	checkNoLeaks(t, before)
+}
+
+func TestMergeGeneratorsNoLeaks(t *testing.T) {
+	before := runtime.NumGoroutine()
+	if got := MergeGenerators(100, 4); len(got) != 400 {
+		t.Fatalf("got %d values, want 400", len(got))
+	}
+	checkNoLeaks(t, before)
+}
@@ -0,0 +1,188 @@
+package fanin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	// ErrMuxShutdown is returned when attaching a source to a Mux that
+	// was shut down.
+	ErrMuxShutdown = errors.New("mux is shut down")
+	// ErrUnknownSource is returned when detaching a source that is not
+	// attached, e.g. because it was closed and drained.
+	ErrUnknownSource = errors.New("unknown source")
+)
+
+// A Mux merges a changing set of source channels into one output. Unlike
+// Merge, sources can be attached and detached while it runs. The output is
+// closed once Shutdown was called and every source still attached has
+// been drained to its close, or once ctx is cancelled.
+//
+// A Mux is safe for concurrent use.
+type Mux[T any] struct {
+	ctx context.Context
+	out chan T
+
+	mu      sync.Mutex
+	sources map[int]*muxSource
+	nextID  int
+	// shutdown is closed by Shutdown, after which no source is attached.
+	shutdown chan struct{}
+	// stopWatch stops ctx from calling Shutdown.
+	stopWatch func() bool
+	wg        sync.WaitGroup
+}
+
+type muxSource struct {
+	id        int
+	label     string
+	forwarded atomic.Int64
+	stop      chan struct{}
+	stopOnce  sync.Once
+	// done is closed when the source's forwarder has exited.
+	done chan struct{}
+}
+
+func (s *muxSource) stats() SourceStats {
+	return SourceStats{ID: s.id, Label: s.label, Forwarded: s.forwarded.Load()}
+}
+
+// SourceStats are the counters of a source attached to a Mux.
+type SourceStats struct {
+	ID    int
+	Label string
+	// Forwarded is the number of values sent on from the source.
+	Forwarded int64
+}
+
+func (s SourceStats) String() string {
+	if s.Label == "" {
+		return fmt.Sprintf("source %d: %d forwarded", s.ID, s.Forwarded)
+	}
+	return fmt.Sprintf("source %d (%s): %d forwarded", s.ID, s.Label, s.Forwarded)
+}
+
+// NewMux returns a Mux with no sources, whose output holds up to size
+// values. Cancelling ctx stops every source and shuts the Mux down. The
+// output is only ever closed by Shutdown or ctx, so a Mux needs one of
+// them; no goroutine waits on ctx in the meantime.
+func NewMux[T any](ctx context.Context, size int) *Mux[T] {
+	m := &Mux[T]{
+		ctx:      ctx,
+		out:      make(chan T, size),
+		sources:  make(map[int]*muxSource),
+		shutdown: make(chan struct{}),
+	}
+	// Shutdown may run at once for a done ctx; it waits for stopWatch.
+	m.mu.Lock()
+	m.stopWatch = context.AfterFunc(ctx, m.Shutdown)
+	m.mu.Unlock()
+	return m
+}
+
+// Out returns the output channel.
+func (m *Mux[T]) Out() <-chan T {
+	return m.out
+}
+
+// Attach starts forwarding the values of ch until it is closed or
+// detached, and returns the ID to detach it with. The label, which may be
+// empty, only shows in the source's SourceStats.
+func (m *Mux[T]) Attach(label string, ch <-chan T) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	select {
+	case <-m.shutdown:
+		return 0, ErrMuxShutdown
+	default:
+	}
+	m.nextID++
+	s := &muxSource{id: m.nextID, label: label, stop: make(chan struct{}), done: make(chan struct{})}
+	m.sources[s.id] = s
+	m.wg.Add(1)
+	go m.forward(s, ch)
+	return s.id, nil
+}
+
+func (m *Mux[T]) forward(s *muxSource, ch <-chan T) {
+	defer m.wg.Done()
+	defer close(s.done)
+	defer func() {
+		m.mu.Lock()
+		delete(m.sources, s.id)
+		m.mu.Unlock()
+	}()
+	for {
+		select {
+		case <-m.ctx.Done():
+			return
+		case <-s.stop:
+			return
+		case v, ok := <-ch:
+			if !ok {
+				return
+			}
+			select {
+			case <-m.ctx.Done():
+				return
+			case <-s.stop:
+				return
+			case m.out <- v:
+				s.forwarded.Add(1)
+			}
+		}
+	}
+}
+
+// Detach stops forwarding from a source and returns its final counters.
+// Values still in the source's channel are left there, and none of its
+// values are sent once Detach returns.
+func (m *Mux[T]) Detach(id int) (SourceStats, error) {
+	m.mu.Lock()
+	s, ok := m.sources[id]
+	m.mu.Unlock()
+	if !ok {
+		return SourceStats{}, fmt.Errorf("%w: %d", ErrUnknownSource, id)
+	}
+	s.stopOnce.Do(func() { close(s.stop) })
+	<-s.done
+	return s.stats(), nil
+}
+
+// Stats returns the counters of the attached sources, in the order they
+// were attached.
+func (m *Mux[T]) Stats() []SourceStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var stats []SourceStats
+	for _, id := range slices.Sorted(maps.Keys(m.sources)) {
+		stats = append(stats, m.sources[id].stats())
+	}
+	return stats
+}
+
+// Shutdown stops the Mux from taking new sources. The output is closed
+// once the attached sources are closed and drained, or detached. Calling
+// Shutdown more than once does nothing.
+func (m *Mux[T]) Shutdown() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	select {
+	case <-m.shutdown:
+		return
+	default:
+	}
+	close(m.shutdown)
+	m.stopWatch()
+	// No Attach can call wg.Add from here on.
+	go func() {
+		m.wg.Wait()
+		close(m.out)
+	}()
+}
@@ -0,0 +1,211 @@
+package fanin
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// closedWithin reports whether ch is closed within d, failing on a value.
+func closedWithin[T any](t *testing.T, ch <-chan T, d time.Duration) bool {
+	t.Helper()
+	select {
+	case v, ok := <-ch:
+		if ok {
+			t.Fatalf("got %v, want the channel closed", v)
+		}
+		return true
+	case <-time.After(d):
+		return false
+	}
+}
+
+func TestMux(t *testing.T) {
+	m := NewMux[int](context.Background(), 0)
+	if _, err := m.Attach("a", source(1, 2, 3)); err != nil {
+		t.Fatal(err)
+	}
+	var got []int
+	for range 3 {
+		got = append(got, <-m.Out())
+	}
+	// A Mux without sources stays open until it is shut down.
+	if closedWithin(t, m.Out(), 20*time.Millisecond) {
+		t.Fatal("output closed before Shutdown")
+	}
+
+	late := make(chan int)
+	id, err := m.Attach("late", late)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Shutdown()
+	m.Shutdown()
+	if _, err := m.Attach("too late", make(chan int)); !errors.Is(err, ErrMuxShutdown) {
+		t.Errorf("Attach after Shutdown: error = %v, want ErrMuxShutdown", err)
+	}
+
+	// Shut down, but the late source is still attached and is drained.
+	go func() {
+		late <- 4
+		late <- 5
+	}()
+	got = append(got, <-m.Out(), <-m.Out())
+	// The count goes up just after the value is taken.
+	want := []SourceStats{{ID: id, Label: "late", Forwarded: 2}}
+	for deadline := time.Now().Add(5 * time.Second); !slices.Equal(m.Stats(), want); {
+		if time.Now().After(deadline) {
+			t.Fatalf("Stats = %v, want %v", m.Stats(), want)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if closedWithin(t, m.Out(), 20*time.Millisecond) {
+		t.Fatal("output closed before the late source")
+	}
+	close(late)
+	if !closedWithin(t, m.Out(), 5*time.Second) {
+		t.Fatal("output not closed after Shutdown and every source drained")
+	}
+	slices.Sort(got)
+	if !slices.Equal(got, []int{1, 2, 3, 4, 5}) {
+		t.Errorf("got %v", got)
+	}
+}
+
+func TestMuxDetach(t *testing.T) {
+	before := runtime.NumGoroutine()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	m := NewMux[int](ctx, 0)
+
+	endless, _ := m.Attach("endless", generator(ctx, 1<<30))
+	for range 5 {
+		<-m.Out()
+	}
+	// Another source, not read from while the first is detached.
+	idle, _ := m.Attach("", make(chan int))
+	if stats := m.Stats(); len(stats) != 2 || stats[0].Label != "endless" || stats[1].String() != "source 2: 0 forwarded" {
+		t.Errorf("Stats = %v", stats)
+	}
+
+	stats, err := m.Detach(endless)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.ID != endless || stats.Forwarded < 5 {
+		t.Errorf("Detach = %v, want at least 5 forwarded", stats)
+	}
+	// Nothing of it comes after Detach.
+	select {
+	case <-m.Out():
+		t.Error("value after Detach returned")
+	default:
+	}
+	if _, err := m.Detach(endless); !errors.Is(err, ErrUnknownSource) {
+		t.Errorf("detaching twice: error = %v, want ErrUnknownSource", err)
+	}
+
+	// A detached source counts as drained.
+	m.Shutdown()
+	if _, err := m.Detach(idle); err != nil {
+		t.Fatal(err)
+	}
+	if !closedWithin(t, m.Out(), 5*time.Second) {
+		t.Fatal("output not closed after detaching the last source")
+	}
+	cancel()
+	checkNoLeaks(t, before)
+}
+
+func TestMuxCancel(t *testing.T) {
+	before := runtime.NumGoroutine()
+	ctx, cancel := context.WithCancel(context.Background())
+	m := NewMux[int](ctx, 4)
+	for range 3 {
+		if _, err := m.Attach("", generator(ctx, 1<<30)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m.Attach("blocked", make(chan int))
+	<-m.Out()
+	cancel()
+
+	deadline := time.After(5 * time.Second)
+	for open := true; open; {
+		select {
+		case _, open = <-m.Out():
+		case <-deadline:
+			t.Fatal("output not closed after cancel")
+		}
+	}
+	if _, err := m.Attach("", make(chan int)); !errors.Is(err, ErrMuxShutdown) {
+		t.Errorf("Attach after cancel: error = %v, want ErrMuxShutdown", err)
+	}
+	checkNoLeaks(t, before)
+}
+
+func TestMuxConcurrent(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	m := NewMux[int](ctx, 16)
+	total := 0
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range m.Out() {
+			total++
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			id, err := m.Attach("worker", generator(ctx, 100))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			m.Stats()
+			if i%2 == 0 {
+				// It may have drained already.
+				if _, err := m.Detach(id); err != nil && !errors.Is(err, ErrUnknownSource) {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	m.Shutdown()
+	<-done
+	if total < 10*100 || total > 20*100 {
+		t.Errorf("%d values, want between 1000 and 2000", total)
+	}
+}
+
+func TestMuxIdleNoGoroutines(t *testing.T) {
+	before := runtime.NumGoroutine()
+	for range 100 {
+		NewMux[int](context.Background(), 0)
+		ctx, cancel := context.WithCancel(context.Background())
+		NewMux[int](ctx, 0)
+		defer cancel()
+	}
+	if n := runtime.NumGoroutine(); n > before {
+		t.Errorf("%d goroutines after making idle muxes, want %d", n, before)
+	}
+}
+
+func TestMuxCancelledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	m := NewMux[int](ctx, 0)
+	if !closedWithin(t, m.Out(), 5*time.Second) {
+		t.Fatal("output of a mux with a done context not closed")
+	}
+}
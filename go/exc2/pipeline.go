@@ -0,0 +1,238 @@
+package fanin
+
+import (
+	"context"
+	"iter"
+	"time"
+)
+
+// The stages below each read one channel and return new ones, so they
+// chain into pipelines with generator, Merge and MergeSorted. Every stage
+// runs in its own goroutine and closes its outputs when its input is
+// closed or ctx is cancelled; to stop a pipeline early, cancel ctx rather
+// than walking away from its output.
+
+// send sends v on out unless ctx is done first, and reports whether it did.
+func send[T any](ctx context.Context, out chan<- T, v T) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case out <- v:
+		return true
+	}
+}
+
+// Map sends f of every value of in.
+func Map[T, U any](ctx context.Context, in <-chan T, f func(T) U) <-chan U {
+	out := make(chan U)
+	go func() {
+		defer close(out)
+		for v := range receive(ctx, in) {
+			if !send(ctx, out, f(v)) {
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// Filter sends the values of in for which keep returns true.
+func Filter[T any](ctx context.Context, in <-chan T, keep func(T) bool) <-chan T {
+	out := make(chan T)
+	go func() {
+		defer close(out)
+		for v := range receive(ctx, in) {
+			if keep(v) && !send(ctx, out, v) {
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// FlatMap sends every value of f of every value of in, in order. Use
+// slices.Values for an f that returns a slice.
+func FlatMap[T, U any](ctx context.Context, in <-chan T, f func(T) iter.Seq[U]) <-chan U {
+	out := make(chan U)
+	go func() {
+		defer close(out)
+		for v := range receive(ctx, in) {
+			for u := range f(v) {
+				if !send(ctx, out, u) {
+					return
+				}
+			}
+		}
+	}()
+	return out
+}
+
+// Batch groups the values of in into slices of size values, sending a
+// batch early once timeout has passed since its first value. A size of 0
+// or less batches by timeout only, and a timeout of 0 or less by size
+// only. The last batch is sent when in is closed, however small.
+func Batch[T any](ctx context.Context, in <-chan T, size int, timeout time.Duration) <-chan []T {
+	out := make(chan []T)
+	go func() {
+		defer close(out)
+		var batch []T
+		timer := time.NewTimer(timeout)
+		timer.Stop()
+		defer timer.Stop()
+		// expire is nil while there is no batch waiting on the timer.
+		var expire <-chan time.Time
+		flush := func() bool {
+			b := batch
+			batch, expire = nil, nil
+			timer.Stop()
+			return send(ctx, out, b)
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case v, ok := <-in:
+				if !ok {
+					if len(batch) > 0 {
+						flush()
+					}
+					return
+				}
+				if len(batch) == 0 && timeout > 0 {
+					timer.Reset(timeout)
+					expire = timer.C
+				}
+				batch = append(batch, v)
+				if len(batch) == size && !flush() {
+					return
+				}
+			case <-expire:
+				if !flush() {
+					return
+				}
+			}
+		}
+	}()
+	return out
+}
+
+// Broadcast sends every value of in to each of n channels. The outputs
+// move in lockstep: a value goes to all of them before the next is read,
+// so every output must be read, or ctx cancelled.
+func Broadcast[T any](ctx context.Context, in <-chan T, n int) []<-chan T {
+	outs := make([]chan T, n)
+	recv := make([]<-chan T, n)
+	for i := range outs {
+		outs[i] = make(chan T)
+		recv[i] = outs[i]
+	}
+	go func() {
+		defer func() {
+			for _, out := range outs {
+				close(out)
+			}
+		}()
+		for v := range receive(ctx, in) {
+			for _, out := range outs {
+				if !send(ctx, out, v) {
+					return
+				}
+			}
+		}
+	}()
+	return recv
+}
+
+// Tee sends every value of in to two channels, as Broadcast does.
+func Tee[T any](ctx context.Context, in <-chan T) (<-chan T, <-chan T) {
+	outs := Broadcast(ctx, in, 2)
+	return outs[0], outs[1]
+}
+
+// Take sends the first n values of in and then closes its output. It
+// stops reading in after them, so cancel ctx to stop the stages before.
+func Take[T any](ctx context.Context, in <-chan T, n int) <-chan T {
+	out := make(chan T)
+	go func() {
+		defer close(out)
+		if n <= 0 {
+			return
+		}
+		taken := 0
+		for v := range receive(ctx, in) {
+			if !send(ctx, out, v) {
+				return
+			}
+			if taken++; taken == n {
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// Throttle sends the values of in no more often than once per interval,
+// holding them back as needed; none are dropped.
+func Throttle[T any](ctx context.Context, in <-chan T, interval time.Duration) <-chan T {
+	out := make(chan T)
+	go func() {
+		defer close(out)
+		timer := time.NewTimer(0)
+		defer timer.Stop()
+		var next time.Time
+		for v := range receive(ctx, in) {
+			if wait := time.Until(next); wait > 0 {
+				timer.Reset(wait)
+				select {
+				case <-ctx.Done():
+					return
+				case <-timer.C:
+				}
+			}
+			if !send(ctx, out, v) {
+				return
+			}
+			next = time.Now().Add(interval)
+		}
+	}()
+	return out
+}
+
+// Debounce sends a value of in only once no other has followed it for
+// quiet, dropping the ones that were overtaken. The last value is sent
+// when in is closed.
+func Debounce[T any](ctx context.Context, in <-chan T, quiet time.Duration) <-chan T {
+	out := make(chan T)
+	go func() {
+		defer close(out)
+		timer := time.NewTimer(quiet)
+		timer.Stop()
+		defer timer.Stop()
+		var last T
+		// fire is nil while there is no value waiting.
+		var fire <-chan time.Time
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case v, ok := <-in:
+				if !ok {
+					if fire != nil {
+						send(ctx, out, last)
+					}
+					return
+				}
+				last = v
+				timer.Reset(quiet)
+				fire = timer.C
+			case <-fire:
+				fire = nil
+				if !send(ctx, out, last) {
+					return
+				}
+			}
+		}
+	}()
+	return out
+}
@@ -0,0 +1,179 @@
+package fanin
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func collect[T any](ch <-chan T) []T {
+	var vs []T
+	for v := range ch {
+		vs = append(vs, v)
+	}
+	return vs
+}
+
+func TestStages(t *testing.T) {
+	ctx := context.Background()
+
+	squares := collect(Map(ctx, source(1, 2, 3), func(v int) int { return v * v }))
+	if !slices.Equal(squares, []int{1, 4, 9}) {
+		t.Errorf("Map = %v", squares)
+	}
+	odd := collect(Filter(ctx, source(1, 2, 3, 4, 5), func(v int) bool { return v%2 == 1 }))
+	if !slices.Equal(odd, []int{1, 3, 5}) {
+		t.Errorf("Filter = %v", odd)
+	}
+	words := collect(FlatMap(ctx, source("a b", "", "c"), func(s string) iter.Seq[string] {
+		return slices.Values(strings.Fields(s))
+	}))
+	if !slices.Equal(words, []string{"a", "b", "c"}) {
+		t.Errorf("FlatMap = %q", words)
+	}
+	first := collect(Take(ctx, generator(ctx, 100), 3))
+	if !slices.Equal(first, []int{0, 1, 2}) {
+		t.Errorf("Take = %v", first)
+	}
+	if got := collect(Take(ctx, source(1, 2), 5)); !slices.Equal(got, []int{1, 2}) {
+		t.Errorf("Take of more than there is = %v", got)
+	}
+	if got := collect(Take(ctx, source(1, 2), 0)); got != nil {
+		t.Errorf("Take 0 = %v", got)
+	}
+}
+
+func TestBatch(t *testing.T) {
+	ctx := context.Background()
+	got := collect(Batch(ctx, source(1, 2, 3, 4, 5, 6, 7), 3, 0))
+	if fmt.Sprint(got) != "[[1 2 3] [4 5 6] [7]]" {
+		t.Errorf("Batch by size = %v", got)
+	}
+
+	// Two values, a pause longer than the timeout, then three more.
+	in := make(chan int)
+	go func() {
+		defer close(in)
+		in <- 1
+		in <- 2
+		time.Sleep(100 * time.Millisecond)
+		for v := range 3 {
+			in <- 3 + v
+		}
+	}()
+	got = collect(Batch(ctx, in, 10, 20*time.Millisecond))
+	if fmt.Sprint(got) != "[[1 2] [3 4 5]]" {
+		t.Errorf("Batch by timeout = %v", got)
+	}
+}
+
+func TestBroadcast(t *testing.T) {
+	ctx := context.Background()
+	outs := Broadcast(ctx, source(1, 2, 3), 3)
+	got := make([][]int, len(outs))
+	var wg sync.WaitGroup
+	for i, out := range outs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			got[i] = collect(out)
+		}()
+	}
+	wg.Wait()
+	for i := range got {
+		if !slices.Equal(got[i], []int{1, 2, 3}) {
+			t.Errorf("output %d = %v", i, got[i])
+		}
+	}
+
+	a, b := Tee(ctx, source("x", "y"))
+	var fromA []string
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		fromA = collect(a)
+	}()
+	fromB := collect(b)
+	wg.Wait()
+	if !slices.Equal(fromA, []string{"x", "y"}) || !slices.Equal(fromB, fromA) {
+		t.Errorf("Tee = %q, %q", fromA, fromB)
+	}
+}
+
+func TestThrottle(t *testing.T) {
+	const interval = 20 * time.Millisecond
+	start := time.Now()
+	got := collect(Throttle(context.Background(), source(1, 2, 3, 4), interval))
+	if !slices.Equal(got, []int{1, 2, 3, 4}) {
+		t.Errorf("Throttle = %v", got)
+	}
+	if elapsed := time.Since(start); elapsed < 3*interval {
+		t.Errorf("4 values in %v, want at least %v", elapsed, 3*interval)
+	}
+}
+
+func TestDebounce(t *testing.T) {
+	// Bursts separated by pauses longer than the quiet period: only the
+	// last of each burst is sent.
+	in := make(chan int)
+	go func() {
+		defer close(in)
+		for _, burst := range [][]int{{1, 2, 3}, {4}, {5, 6}} {
+			for _, v := range burst {
+				in <- v
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+		in <- 7
+	}()
+	got := collect(Debounce(context.Background(), in, 30*time.Millisecond))
+	if !slices.Equal(got, []int{3, 4, 6, 7}) {
+		t.Errorf("Debounce = %v, want [3 4 6 7]", got)
+	}
+}
+
+func TestPipelineCancelNoLeaks(t *testing.T) {
+	before := runtime.NumGoroutine()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// Every stage in one pipeline, fed forever, read a little and then
+	// abandoned.
+	nums := Merge(ctx, generator(ctx, 1<<30), generator(ctx, 1<<30))
+	evens := Filter(ctx, nums, func(v int) bool { return v%2 == 0 })
+	pairs := FlatMap(ctx, evens, func(v int) iter.Seq[int] { return slices.Values([]int{v, v + 1}) })
+	labels := Map(ctx, Throttle(ctx, pairs, time.Microsecond), strconv.Itoa)
+	batches := Batch(ctx, Debounce(ctx, labels, time.Nanosecond), 4, time.Millisecond)
+	a, b := Tee(ctx, Take(ctx, batches, 1000))
+	go collect(b)
+	for range 3 {
+		<-a
+	}
+	cancel()
+
+	checkNoLeaks(t, before)
+}
+
+// A sum of squares of even numbers, from three producers.
+func Example_pipeline() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	nums := Merge(ctx, generator(ctx, 10), generator(ctx, 10), generator(ctx, 10))
+	evens := Filter(ctx, nums, func(v int) bool { return v%2 == 0 })
+	squares := Map(ctx, evens, func(v int) int { return v * v })
+	sum := 0
+	for batch := range Batch(ctx, squares, 4, 0) {
+		for _, v := range batch {
+			sum += v
+		}
+	}
+	fmt.Println(sum)
+	// Output: 360
+}
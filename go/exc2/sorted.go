@@ -0,0 +1,141 @@
+package fanin
+
+import (
+	"container/heap"
+	"context"
+	"iter"
+)
+
+// MergeSorted merges channels that each deliver values in the order of
+// cmp into one channel in that order. It holds one value per open channel
+// in a heap, so it waits for a value from every channel still open before
+// sending the smallest; channels may close at different times. The output
+// is closed once every channel is closed or ctx is cancelled.
+//
+// Equal values from different channels come out in no particular order;
+// see MergeSortedStable.
+func MergeSorted[T any](ctx context.Context, cmp func(a, b T) int, chans ...<-chan T) <-chan T {
+	return mergeSorted(ctx, cmp, false, chans)
+}
+
+// MergeSortedStable is MergeSorted with ties broken by source: of equal
+// values, the one from the channel earlier in chans comes out first.
+func MergeSortedStable[T any](ctx context.Context, cmp func(a, b T) int, chans ...<-chan T) <-chan T {
+	return mergeSorted(ctx, cmp, true, chans)
+}
+
+// MergeSortedSeq merges sequences that are each in the order of cmp into
+// one sequence in that order. Sequences are pulled one value at a time and
+// stopped when the merged sequence ends or its consumer stops early.
+func MergeSortedSeq[T any](cmp func(a, b T) int, seqs ...iter.Seq[T]) iter.Seq[T] {
+	return mergeSortedSeq(cmp, false, seqs)
+}
+
+// MergeSortedSeqStable is MergeSortedSeq with ties broken by source, as
+// in MergeSortedStable.
+func MergeSortedSeqStable[T any](cmp func(a, b T) int, seqs ...iter.Seq[T]) iter.Seq[T] {
+	return mergeSortedSeq(cmp, true, seqs)
+}
+
+func mergeSorted[T any](ctx context.Context, cmp func(a, b T) int, stable bool, chans []<-chan T) <-chan T {
+	out := make(chan T)
+	seqs := make([]iter.Seq[T], len(chans))
+	for i, ch := range chans {
+		seqs[i] = receive(ctx, ch)
+	}
+
+	go func() {
+		defer close(out)
+		for v := range mergeSortedSeq(cmp, stable, seqs) {
+			// A cancelled source looks closed, so check before sending
+			// what is left in the heap.
+			if ctx.Err() != nil {
+				return
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case out <- v:
+			}
+		}
+	}()
+	return out
+}
+
+// receive returns the values of ch as a sequence, which ends when ch is
+// closed or ctx is done.
+func receive[T any](ctx context.Context, ch <-chan T) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case v, ok := <-ch:
+				if !ok || !yield(v) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func mergeSortedSeq[T any](cmp func(a, b T) int, stable bool, seqs []iter.Seq[T]) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		h := &heads[T]{cmp: cmp, stable: stable}
+		for i, seq := range seqs {
+			next, stop := iter.Pull(seq)
+			defer stop()
+			if v, ok := next(); ok {
+				h.items = append(h.items, head[T]{value: v, source: i, next: next})
+			}
+		}
+		heap.Init(h)
+
+		for h.Len() > 0 {
+			top := &h.items[0]
+			if !yield(top.value) {
+				return
+			}
+			if v, ok := top.next(); ok {
+				top.value = v
+				heap.Fix(h, 0)
+			} else {
+				heap.Pop(h)
+			}
+		}
+	}
+}
+
+// head is the next value of a source.
+type head[T any] struct {
+	value  T
+	source int
+	next   func() (T, bool)
+}
+
+// heads is a min-heap of the next value of each source.
+type heads[T any] struct {
+	items  []head[T]
+	cmp    func(a, b T) int
+	stable bool
+}
+
+func (h *heads[T]) Len() int { return len(h.items) }
+
+func (h *heads[T]) Less(i, j int) bool {
+	c := h.cmp(h.items[i].value, h.items[j].value)
+	if c == 0 && h.stable {
+		return h.items[i].source < h.items[j].source
+	}
+	return c < 0
+}
+
+func (h *heads[T]) Swap(i, j int) { h.items[i], h.items[j] = h.items[j], h.items[i] }
+
+func (h *heads[T]) Push(x any) { h.items = append(h.items, x.(head[T])) }
+
+func (h *heads[T]) Pop() any {
+	last := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return last
+}
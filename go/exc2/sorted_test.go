@@ -0,0 +1,128 @@
+package fanin
+
+import (
+	"cmp"
+	"context"
+	"runtime"
+	"slices"
+	"testing"
+	"time"
+)
+
+// entry is a log line: sorted by time, tagged with where it came from.
+type entry struct {
+	time int
+	from string
+}
+
+func byTime(a, b entry) int { return cmp.Compare(a.time, b.time) }
+
+func entries(from string, times ...int) []entry {
+	var es []entry
+	for _, t := range times {
+		es = append(es, entry{time: t, from: from})
+	}
+	return es
+}
+
+func TestMergeSorted(t *testing.T) {
+	var got []int
+	for v := range MergeSorted(context.Background(), cmp.Compare[int],
+		source(1, 4, 9, 12), source(2, 3), source[int](), source(0, 5, 6, 7, 8, 10, 11)) {
+		got = append(got, v)
+	}
+	if want := []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}; !slices.Equal(got, want) {
+		t.Errorf("MergeSorted = %v, want %v", got, want)
+	}
+
+	// A source that is slow to send holds the others back, since its next
+	// value could be the smallest.
+	slow := make(chan int)
+	go func() {
+		defer close(slow)
+		for _, v := range []int{2, 6} {
+			time.Sleep(20 * time.Millisecond)
+			slow <- v
+		}
+	}()
+	got = nil
+	for v := range MergeSorted(context.Background(), cmp.Compare[int], source(1, 3, 5, 7), slow) {
+		got = append(got, v)
+	}
+	if want := []int{1, 2, 3, 5, 6, 7}; !slices.Equal(got, want) {
+		t.Errorf("MergeSorted with a slow source = %v, want %v", got, want)
+	}
+}
+
+func TestMergeSortedStable(t *testing.T) {
+	a := entries("a", 1, 2, 2, 5)
+	b := entries("b", 2, 3, 5)
+	c := entries("c", 0, 2, 5, 5)
+	want := []entry{
+		{0, "c"}, {1, "a"}, {2, "a"}, {2, "a"}, {2, "b"}, {2, "c"},
+		{3, "b"}, {5, "a"}, {5, "b"}, {5, "c"}, {5, "c"},
+	}
+
+	var got []entry
+	for e := range MergeSortedStable(context.Background(), byTime, source(a...), source(b...), source(c...)) {
+		got = append(got, e)
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("MergeSortedStable = %v, want %v", got, want)
+	}
+
+	got = slices.Collect(MergeSortedSeqStable(byTime, slices.Values(a), slices.Values(b), slices.Values(c)))
+	if !slices.Equal(got, want) {
+		t.Errorf("MergeSortedSeqStable = %v, want %v", got, want)
+	}
+
+	// Without the stable mode the times are still in order.
+	got = slices.Collect(MergeSortedSeq(byTime, slices.Values(a), slices.Values(b), slices.Values(c)))
+	if !slices.IsSortedFunc(got, byTime) || len(got) != len(want) {
+		t.Errorf("MergeSortedSeq = %v", got)
+	}
+}
+
+func TestMergeSortedSeqStopsSources(t *testing.T) {
+	stopped := 0
+	counting := func(start int) func(func(int) bool) {
+		return func(yield func(int) bool) {
+			defer func() { stopped++ }()
+			for i := start; ; i += 2 {
+				if !yield(i) {
+					return
+				}
+			}
+		}
+	}
+
+	var got []int
+	for v := range MergeSortedSeq(cmp.Compare[int], counting(0), counting(1)) {
+		if v == 5 {
+			break
+		}
+		got = append(got, v)
+	}
+	if !slices.Equal(got, []int{0, 1, 2, 3, 4}) || stopped != 2 {
+		t.Errorf("got %v with %d sources stopped, want 0..4 with 2", got, stopped)
+	}
+}
+
+func TestMergeSortedCancelNoLeaks(t *testing.T) {
+	before := runtime.NumGoroutine()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	out := MergeSorted(ctx, cmp.Compare[int], generator(ctx, 1<<30), generator(ctx, 1<<30), make(chan int))
+	cancel()
+	for range out {
+	}
+	checkNoLeaks(t, before)
+
+	ctx, cancel = context.WithCancel(context.Background())
+	out = MergeSortedStable(ctx, cmp.Compare[int], generator(ctx, 1<<30), generator(ctx, 1<<30))
+	for range 10 {
+		<-out
+	}
+	cancel()
+	checkNoLeaks(t, before)
+}